@@ -0,0 +1,200 @@
+package senml
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// ErrInvalidWindow is returned by Downsample for windows which are not positive
+var ErrInvalidWindow = errors.New("window is not positive")
+
+// Bucket is the group of resolved records with the same name in a time window, passed to an Aggregator
+type Bucket struct {
+	// Name is the resolved name of the records
+	Name string
+	// Start is the beginning of the window
+	Start time.Time
+	// Window is the duration of the window
+	Window time.Duration
+	// Records are in the order of time
+	Records Pack
+}
+
+// floats returns the times and float values of the records with a float value
+func (b Bucket) floats() (times, values []float64) {
+	for _, r := range b.Records {
+		if v, ok := r.Float(); ok {
+			times = append(times, r.Time)
+			values = append(values, v)
+		}
+	}
+	return times, values
+}
+
+// Aggregator returns a record with the value or sum aggregating the bucket, or false if there is nothing to aggregate.
+// The Name, Time and UpdateTime of the record are set by Downsample, and the unit if left empty.
+type Aggregator func(b Bucket) (Record, bool)
+
+// Downsample returns a resolved pack with one record per resolved name and time window, aggregated by the aggregator.
+// Windows are aligned to multiples of the window duration since the Unix epoch. The Time of the records is the beginning of
+// the window and the UpdateTime is the window duration. Records are in the order of windows, then of names in the pack.
+// Relative times are resolved with the options of Pack.Normalize, e.g. SetClock, by default using the current time.
+func (p Pack) Downsample(window time.Duration, aggregate Aggregator, options ...NormalizeOption) (Pack, error) {
+	if window <= 0 {
+		return nil, ErrInvalidWindow
+	}
+	seconds := durationToSeconds(window)
+
+	type bucketKey struct {
+		name  string
+		start float64
+	}
+	var keys []bucketKey
+	buckets := make(map[bucketKey]Pack)
+	for _, r := range p.Normalized(options...) {
+		key := bucketKey{name: r.Name, start: math.Floor(r.Time/seconds) * seconds}
+		if _, found := buckets[key]; !found {
+			keys = append(keys, key)
+		}
+		buckets[key] = append(buckets[key], r)
+	}
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i].start < keys[j].start })
+
+	var result Pack
+	for _, key := range keys {
+		records := buckets[key]
+		sort.SliceStable(records, func(i, j int) bool { return records[i].Time < records[j].Time })
+		sec, nsec := splitSeconds(key.start)
+		r, ok := aggregate(Bucket{Name: key.name, Start: time.Unix(sec, nsec), Window: window, Records: records})
+		if !ok {
+			continue
+		}
+		r.Name = key.name
+		r.Time = key.start
+		r.UpdateTime = seconds
+		if r.Unit == "" {
+			r.Unit = records[0].Unit
+		}
+		result = append(result, r)
+	}
+	return result, nil
+}
+
+// floatAggregator returns an aggregator of the float values of the bucket. Records without a float value are ignored.
+func floatAggregator(aggregate func(times, values []float64) float64) Aggregator {
+	return func(b Bucket) (Record, bool) {
+		times, values := b.floats()
+		if len(values) == 0 {
+			return Record{}, false
+		}
+		v := aggregate(times, values)
+		return Record{Value: &v}, true
+	}
+}
+
+// Min aggregates to the minimum of the float values
+func Min() Aggregator {
+	return floatAggregator(func(_, values []float64) float64 {
+		min := values[0]
+		for _, v := range values[1:] {
+			min = math.Min(min, v)
+		}
+		return min
+	})
+}
+
+// Max aggregates to the maximum of the float values
+func Max() Aggregator {
+	return floatAggregator(func(_, values []float64) float64 {
+		max := values[0]
+		for _, v := range values[1:] {
+			max = math.Max(max, v)
+		}
+		return max
+	})
+}
+
+// Mean aggregates to the arithmetic mean of the float values
+func Mean() Aggregator {
+	return floatAggregator(func(_, values []float64) float64 {
+		var sum float64
+		for _, v := range values {
+			sum += v
+		}
+		return sum / float64(len(values))
+	})
+}
+
+// Percentile aggregates to the q-th percentile of the float values, with q from 0 to 100,
+// interpolating linearly between the closest ranks. E.g. Percentile(50) is the median.
+// Values of q out of range are clamped to 0 or 100. There is no aggregate if q is NaN.
+func Percentile(q float64) Aggregator {
+	if math.IsNaN(q) {
+		return func(Bucket) (Record, bool) {
+			return Record{}, false
+		}
+	}
+	q = math.Max(0, math.Min(100, q))
+	return floatAggregator(func(_, values []float64) float64 {
+		sorted := append([]float64(nil), values...)
+		sort.Float64s(sorted)
+		rank := q / 100 * float64(len(sorted)-1)
+		lower := int(math.Floor(rank))
+		if lower == len(sorted)-1 {
+			return sorted[lower]
+		}
+		return sorted[lower] + (rank-float64(lower))*(sorted[lower+1]-sorted[lower])
+	})
+}
+
+// Count aggregates to the number of records of any kind, in the count unit
+func Count() Aggregator {
+	return func(b Bucket) (Record, bool) {
+		n := float64(len(b.Records))
+		return Record{Unit: UnitCount, Value: &n}, true
+	}
+}
+
+// First aggregates to the value, and sum if any, of the earliest record of any kind
+func First() Aggregator {
+	return func(b Bucket) (Record, bool) {
+		return valueFields(b.Records[0]), true
+	}
+}
+
+// Last aggregates to the value, and sum if any, of the latest record of any kind
+func Last() Aggregator {
+	return func(b Bucket) (Record, bool) {
+		return valueFields(b.Records[len(b.Records)-1]), true
+	}
+}
+
+// valueFields returns a copy of the record with only the value and sum fields
+func valueFields(r Record) Record {
+	r = Pack{r}.Clone()[0]
+	return Record{Value: r.Value, StringValue: r.StringValue, DataValue: r.DataValue, BoolValue: r.BoolValue, Sum: r.Sum}
+}
+
+// Integrate aggregates to the integral of the float values over time, as the Sum of the record: https://tools.ietf.org/html/rfc8428#section-4.2
+// Each value is held until the time of the next value, and the last one until the end of the window.
+// The unit of the record is that of the values, e.g. the sum of values in W is in W*s, i.e. J.
+func Integrate() Aggregator {
+	return func(b Bucket) (Record, bool) {
+		times, values := b.floats()
+		if len(values) == 0 {
+			return Record{}, false
+		}
+		end := toSeconds(b.Start.Unix(), int64(b.Start.Nanosecond())) + durationToSeconds(b.Window)
+		var sum float64
+		for i, v := range values {
+			next := end
+			if i+1 < len(times) {
+				next = times[i+1]
+			}
+			sum += v * (next - times[i])
+		}
+		return Record{Sum: &sum}, true
+	}
+}
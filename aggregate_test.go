@@ -0,0 +1,111 @@
+package senml
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDownsample(t *testing.T) {
+	float := func(f float64) *float64 { return &f }
+	p := Pack{
+		{BaseName: "dev/", BaseTime: 1320078420, BaseUnit: UnitWatt, Name: "power", Value: float(10), Time: 0},
+		{Name: "power", Value: float(30), Time: 5},
+		{Name: "status", StringValue: "on", Time: 6},
+		{Name: "power", Value: float(20), Time: 2},
+		{Name: "power", Value: float(40), Time: 12},
+		{Name: "status", StringValue: "off", Time: 13},
+	}
+
+	tests := []struct {
+		name       string
+		aggregator Aggregator
+		expected   Pack
+	}{
+		{"min", Min(), Pack{
+			{Name: "dev/power", Unit: UnitWatt, Value: float(10), Time: 1320078420},
+			{Name: "dev/power", Unit: UnitWatt, Value: float(40), Time: 1320078430},
+		}},
+		{"max", Max(), Pack{
+			{Name: "dev/power", Unit: UnitWatt, Value: float(30), Time: 1320078420},
+			{Name: "dev/power", Unit: UnitWatt, Value: float(40), Time: 1320078430},
+		}},
+		{"mean", Mean(), Pack{
+			{Name: "dev/power", Unit: UnitWatt, Value: float(20), Time: 1320078420},
+			{Name: "dev/power", Unit: UnitWatt, Value: float(40), Time: 1320078430},
+		}},
+		{"median", Percentile(50), Pack{
+			{Name: "dev/power", Unit: UnitWatt, Value: float(20), Time: 1320078420},
+			{Name: "dev/power", Unit: UnitWatt, Value: float(40), Time: 1320078430},
+		}},
+		{"percentile", Percentile(75), Pack{
+			{Name: "dev/power", Unit: UnitWatt, Value: float(25), Time: 1320078420},
+			{Name: "dev/power", Unit: UnitWatt, Value: float(40), Time: 1320078430},
+		}},
+		{"percentile out of range", Percentile(150), Pack{
+			{Name: "dev/power", Unit: UnitWatt, Value: float(30), Time: 1320078420},
+			{Name: "dev/power", Unit: UnitWatt, Value: float(40), Time: 1320078430},
+		}},
+		{"percentile NaN", Percentile(math.NaN()), nil},
+		{"count", Count(), Pack{
+			{Name: "dev/power", Unit: UnitCount, Value: float(3), Time: 1320078420},
+			{Name: "dev/status", Unit: UnitCount, Value: float(1), Time: 1320078420},
+			{Name: "dev/power", Unit: UnitCount, Value: float(1), Time: 1320078430},
+			{Name: "dev/status", Unit: UnitCount, Value: float(1), Time: 1320078430},
+		}},
+		{"first", First(), Pack{
+			{Name: "dev/power", Unit: UnitWatt, Value: float(10), Time: 1320078420},
+			{Name: "dev/status", Unit: UnitWatt, StringValue: "on", Time: 1320078420},
+			{Name: "dev/power", Unit: UnitWatt, Value: float(40), Time: 1320078430},
+			{Name: "dev/status", Unit: UnitWatt, StringValue: "off", Time: 1320078430},
+		}},
+		{"last", Last(), Pack{
+			{Name: "dev/power", Unit: UnitWatt, Value: float(30), Time: 1320078420},
+			{Name: "dev/status", Unit: UnitWatt, StringValue: "on", Time: 1320078420},
+			{Name: "dev/power", Unit: UnitWatt, Value: float(40), Time: 1320078430},
+			{Name: "dev/status", Unit: UnitWatt, StringValue: "off", Time: 1320078430},
+		}},
+		{"integrate", Integrate(), Pack{
+			{Name: "dev/power", Unit: UnitWatt, Sum: float(10*2 + 20*3 + 30*5), Time: 1320078420},
+			{Name: "dev/power", Unit: UnitWatt, Sum: float(40 * 8), Time: 1320078430},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := p.Downsample(10*time.Second, test.aggregator)
+			if err != nil {
+				t.Fatalf("Error downsampling: %s", err)
+			}
+			for i := range test.expected {
+				test.expected[i].UpdateTime = 10
+			}
+			if stringifyPack(result) != stringifyPack(test.expected) {
+				t.Fatalf("Expected:\n%s\nGot:\n%s", stringifyPack(test.expected), stringifyPack(result))
+			}
+		})
+	}
+
+	t.Run("invalid window", func(t *testing.T) {
+		if _, err := p.Downsample(0, Mean()); !errors.Is(err, ErrInvalidWindow) {
+			t.Fatalf("Expected invalid window error. Got: %v", err)
+		}
+	})
+
+	t.Run("options", func(t *testing.T) {
+		relative := Pack{{Name: "power", Value: float(10), Time: -12}, {Name: "power", Value: float(20), Time: -1}}
+		clock := SetClock(func() time.Time { return time.Unix(1320078430, 0) })
+		downsampled, err := relative.Downsample(10*time.Second, Count(), clock)
+		if err != nil {
+			t.Fatalf("Error downsampling: %s", err)
+		}
+		if len(downsampled) != 2 || downsampled[0].Time != 1320078410 || downsampled[1].Time != 1320078420 {
+			t.Fatalf("Unexpected records: %s", stringifyPack(downsampled))
+		}
+	})
+
+	if p[0].BaseName != "dev/" || p[1].Time != 5 {
+		t.Fatalf("Pack was modified: %s", stringifyPack(p))
+	}
+}
@@ -0,0 +1,120 @@
+package senml
+
+import (
+	"encoding/base64"
+	"errors"
+	"time"
+)
+
+// ErrBaseNameReset is returned by Builder.Pack when the base name is reset to empty after records with a base name.
+// An empty base name is absent in SenML, so the previous base name would still apply.
+var ErrBaseNameReset = errors.New("base name cannot be reset to empty")
+
+// Builder constructs a SenML Pack from Go values, validating each record as it is added.
+// The first validation error is kept and returned by Pack; records added after an error are ignored.
+//
+// The zero value is an empty builder ready to use.
+type Builder struct {
+	pack     Pack
+	baseName string
+	newBase  bool
+	time     float64
+	err      error
+}
+
+// NewBuilder returns an empty builder
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// BaseName sets the base name of the records added afterwards. It is set as the BaseName of the next record.
+// Once a record with a base name is added, the base name cannot be reset to empty; see ErrBaseNameReset.
+func (b *Builder) BaseName(name string) *Builder {
+	if b.err != nil {
+		return b
+	}
+	if name == "" && b.hasBaseName() {
+		b.err = ErrBaseNameReset
+		return b
+	}
+	b.baseName = name
+	b.newBase = true
+	return b
+}
+
+// At sets the time of the records added afterwards. The zero time resets records to the time of the reading.
+func (b *Builder) At(t time.Time) *Builder {
+	if t.IsZero() {
+		b.time = 0
+	} else {
+		b.time = toSeconds(t.Unix(), int64(t.Nanosecond()))
+	}
+	return b
+}
+
+// Float adds a record with a float value
+func (b *Builder) Float(name, unit string, v float64) *Builder {
+	return b.add(Record{Name: name, Unit: unit, Value: &v})
+}
+
+// Bool adds a record with a boolean value
+func (b *Builder) Bool(name string, v bool) *Builder {
+	return b.add(Record{Name: name, BoolValue: &v})
+}
+
+// String adds a record with a string value
+func (b *Builder) String(name, v string) *Builder {
+	return b.add(Record{Name: name, StringValue: v})
+}
+
+// Data adds a record with a data value, encoded in base64 with the URL-safe alphabet
+func (b *Builder) Data(name string, v []byte) *Builder {
+	return b.add(Record{Name: name, DataValue: base64.RawURLEncoding.EncodeToString(v)})
+}
+
+// Sum adds a record with the integrated sum of the values over time
+func (b *Builder) Sum(name, unit string, s float64) *Builder {
+	return b.add(Record{Name: name, Unit: unit, Sum: &s})
+}
+
+// Pack returns the constructed pack, or the first error
+func (b *Builder) Pack() (Pack, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.pack.Clone(), nil
+}
+
+// hasBaseName reports whether a record with a base name was added
+func (b *Builder) hasBaseName() bool {
+	for i := range b.pack {
+		if b.pack[i].BaseName != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Builder) add(r Record) *Builder {
+	if b.err != nil {
+		return b
+	}
+	r.Time = b.time
+
+	// validate the record with the base name applied
+	resolved := r
+	resolved.BaseName = b.baseName
+	if errs := (Pack{resolved}).validate(false); len(errs) > 0 {
+		err := *errs[0]
+		err.Index = len(b.pack)
+		b.err = &err
+		return b
+	}
+
+	if b.newBase {
+		r.BaseName = b.baseName
+		b.newBase = false
+	}
+	b.pack = append(b.pack, r)
+	return b
+}
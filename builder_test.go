@@ -0,0 +1,93 @@
+package senml
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBuilder(t *testing.T) {
+
+	t.Run("values", func(t *testing.T) {
+		at := time.Unix(1276020076, 1000000)
+		p, err := NewBuilder().
+			BaseName("urn:dev:ow:10e2073a01080063:").
+			At(at).
+			Float("temp", UnitCelsius, 23.1).
+			Bool("open", false).
+			String("label", "Machine Room").
+			Data("nfc-reader", []byte("hi \n")).
+			Sum("energy", "J", 1000).
+			Pack()
+		if err != nil {
+			t.Fatalf("Error building pack: %s", err)
+		}
+		expected := `[{"bn":"urn:dev:ow:10e2073a01080063:","n":"temp","u":"Cel","t":1276020076.001,"v":23.1},` +
+			`{"n":"open","t":1276020076.001,"vb":false},` +
+			`{"n":"label","t":1276020076.001,"vs":"Machine Room"},` +
+			`{"n":"nfc-reader","t":1276020076.001,"vd":"aGkgCg"},` +
+			`{"n":"energy","u":"J","t":1276020076.001,"s":1000}]`
+		if stringifyPack(p) != expected {
+			t.Fatalf("Expected: %s. Got: %s", expected, stringifyPack(p))
+		}
+		if err := p.Validate(); err != nil {
+			t.Fatalf("Built pack is not valid: %s", err)
+		}
+	})
+
+	t.Run("base name changes", func(t *testing.T) {
+		var b Builder
+		p, err := b.BaseName("dev1/").Float("temp", "", 1).Float("hum", "", 2).BaseName("dev2/").Float("temp", "", 3).Pack()
+		if err != nil {
+			t.Fatalf("Error building pack: %s", err)
+		}
+		p.Normalize()
+		for i, name := range []string{"dev1/temp", "dev1/hum", "dev2/temp"} {
+			if p[i].Name != name {
+				t.Fatalf("Expected name %s for record %d. Got %s", name, i, p[i].Name)
+			}
+		}
+	})
+
+	t.Run("base name reset", func(t *testing.T) {
+		_, err := NewBuilder().BaseName("dev1/").Float("temp", "", 1).BaseName("").Float("temp", "", 2).Pack()
+		if !errors.Is(err, ErrBaseNameReset) {
+			t.Fatalf("Expected base name reset error. Got: %v", err)
+		}
+
+		p, err := NewBuilder().BaseName("dev1/").BaseName("").Float("temp", "", 1).Pack()
+		if err != nil {
+			t.Fatalf("Error building pack: %s", err)
+		}
+		if p[0].BaseName != "" || p[0].Name != "temp" {
+			t.Fatalf("Unexpected record: %v", p[0])
+		}
+	})
+
+	t.Run("first error", func(t *testing.T) {
+		b := NewBuilder().Float("temp", "", 1).Float("temp#2", "", 2).Float("temp", "", math.NaN())
+		_, err := b.Pack()
+		var verr *ValidationError
+		if !errors.As(err, &verr) || verr.Index != 1 || !errors.Is(err, ErrInvalidName) {
+			t.Fatalf("Expected invalid name error for record 1. Got: %v", err)
+		}
+	})
+
+	t.Run("not finite", func(t *testing.T) {
+		_, err := NewBuilder().Float("temp", "", math.Inf(1)).Pack()
+		if !errors.Is(err, ErrNotFinite) {
+			t.Fatalf("Expected not finite error. Got: %v", err)
+		}
+	})
+
+	t.Run("pack is a copy", func(t *testing.T) {
+		b := NewBuilder().Float("temp", "", 1)
+		p, _ := b.Pack()
+		*p[0].Value = 2
+		p, _ = b.Pack()
+		if *p[0].Value != 1 {
+			t.Fatalf("Builder was modified through the returned pack")
+		}
+	})
+}
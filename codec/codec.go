@@ -3,8 +3,10 @@
 package codec
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/farshidtz/senml/v2"
 )
@@ -21,32 +23,30 @@ type Reader func(r io.Reader, options ...Option) (senml.Pack, error)
 // Write is the writing function type
 type Writer func(p senml.Pack, w io.Writer, options ...Option) error
 
-// Encode is a convenient function to call the encoding functions using the corresponding media type
-func Encode(mediaType string, p senml.Pack, options ...Option) ([]byte, error) {
-	switch mediaType {
-	case senml.MediaTypeSenmlJSON:
-		return EncodeJSON(p, options...)
-	case senml.MediaTypeSenmlXML:
-		return EncodeXML(p, options...)
-	case senml.MediaTypeSenmlCBOR:
-		return EncodeCBOR(p)
-	case senml.MediaTypeCustomSenmlCSV:
-		return EncodeCSV(p, options...)
+// ErrUnsupportedMediaType is returned for media types without a registered codec
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+// checkMustUnderstand returns an error for records with must-understand extensions,
+// which cannot be dropped by encoders without extensions: https://tools.ietf.org/html/rfc8428#section-4.4
+func checkMustUnderstand(p senml.Pack) error {
+	for i := range p {
+		for label := range p[i].Extensions {
+			if strings.HasSuffix(label, "_") {
+				return fmt.Errorf("record %d: %w: %s", i, senml.ErrMustUnderstand, label)
+			}
+		}
 	}
-	return nil, fmt.Errorf("unsupported media type: %s", mediaType)
+	return nil
 }
 
-// Decode is a convenient function to call the decoding functions using the corresponding media type
+// Encode is a convenient function to call the encoding functions using the corresponding media type.
+// The codecs are looked up in the DefaultRegistry.
+func Encode(mediaType string, p senml.Pack, options ...Option) ([]byte, error) {
+	return DefaultRegistry.Encode(mediaType, p, options...)
+}
+
+// Decode is a convenient function to call the decoding functions using the corresponding media type.
+// The codecs are looked up in the DefaultRegistry.
 func Decode(mediaType string, b []byte, options ...Option) (senml.Pack, error) {
-	switch mediaType {
-	case senml.MediaTypeSenmlJSON:
-		return DecodeJSON(b)
-	case senml.MediaTypeSenmlXML:
-		return DecodeXML(b)
-	case senml.MediaTypeSenmlCBOR:
-		return DecodeCBOR(b)
-	case senml.MediaTypeCustomSenmlCSV:
-		return DecodeCSV(b, options...)
-	}
-	return nil, fmt.Errorf("unsupported media type: %s", mediaType)
+	return DefaultRegistry.Decode(mediaType, b, options...)
 }
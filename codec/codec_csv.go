@@ -5,52 +5,295 @@ import (
 	"encoding/csv"
 	"fmt"
 	"io"
+	"math"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/farshidtz/senml/v2"
 )
 
-// DefaultCSVHeader is the default (currently fixed) CSV header
+// DefaultCSVHeader is the default CSV header
 const DefaultCSVHeader = "Time,Update Time,Name,Unit,Value,String Value,Boolean Value,Data Value,Sum"
 
-// WriteCSV serializes and writes the Pack on the given writer
+// BaseFieldsCSVHeader is the CSV header with base field columns; see SetBaseFields
+const BaseFieldsCSVHeader = DefaultCSVHeader + ",Base Name,Base Time,Base Unit,Base Version,Base Value,Base Sum"
+
+// CSVField is a SenML field which can be mapped to a CSV column.
+// Columns are matched to fields by the names of the BaseFieldsCSVHeader or the SenML labels, e.g. "Value" or "v",
+// case-insensitively, unless mapped otherwise with SetColumnMapping.
+type CSVField int
+
+// CSV fields in the order of the BaseFieldsCSVHeader
+const (
+	CSVTime CSVField = iota
+	CSVUpdateTime
+	CSVName
+	CSVUnit
+	CSVValue
+	CSVStringValue
+	CSVBoolValue
+	CSVDataValue
+	CSVSum
+	CSVBaseName
+	CSVBaseTime
+	CSVBaseUnit
+	CSVBaseVersion
+	CSVBaseValue
+	CSVBaseSum
+)
+
+// csvIgnored marks a column without a field
+const csvIgnored CSVField = -1
+
+var csvFieldLabels = [...]string{"t", "ut", "n", "u", "v", "vs", "vb", "vd", "s", "bn", "bt", "bu", "bver", "bv", "bs"}
+
+func (f CSVField) String() string {
+	if f < CSVTime || f > CSVBaseSum {
+		return "CSVField(" + strconv.Itoa(int(f)) + ")"
+	}
+	return splitHeader(BaseFieldsCSVHeader)[f]
+}
+
+func (f CSVField) isBase() bool {
+	return f >= CSVBaseName && f <= CSVBaseSum
+}
+
+func splitHeader(header string) []string {
+	return strings.Split(header, ",")
+}
+
+// csvFieldOf returns the field of the column name
+func csvFieldOf(name string, mapping map[string]CSVField) (CSVField, bool) {
+	if f, found := mapping[name]; found {
+		return f, true
+	}
+	for f := CSVTime; f <= CSVBaseSum; f++ {
+		if strings.EqualFold(name, f.String()) || strings.EqualFold(name, csvFieldLabels[f]) {
+			return f, true
+		}
+	}
+	return csvIgnored, false
+}
+
+// csvColumns returns the fields of the columns. Unknown columns are an error if strict, otherwise ignored.
+func csvColumns(names []string, mapping map[string]CSVField, strict bool) ([]CSVField, error) {
+	columns := make([]CSVField, len(names))
+	seen := make(map[CSVField]bool)
+	for i, name := range names {
+		f, found := csvFieldOf(strings.TrimSpace(name), mapping)
+		if !found && strict {
+			return nil, fmt.Errorf("unknown column: %s", name)
+		}
+		if found && seen[f] {
+			return nil, fmt.Errorf("duplicate column for %s: %s", f, name)
+		}
+		seen[f] = found
+		columns[i] = f
+	}
+	return columns, nil
+}
+
+func hasCSVField(columns []CSVField) bool {
+	for _, f := range columns {
+		if f != csvIgnored {
+			return true
+		}
+	}
+	return false
+}
+
+func hasBaseCSVField(columns []CSVField) bool {
+	for _, f := range columns {
+		if f.isBase() {
+			return true
+		}
+	}
+	return false
+}
+
+// formatCSVField returns the value of the field of the record
+func formatCSVField(r senml.Record, f CSVField, o *codecOptions) string {
+	formatFloat := func(f *float64) string {
+		if f == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*f, 'f', -1, 64)
+	}
+	switch f {
+	case CSVTime:
+		if o.timeFormat != "" {
+			sec, frac := math.Modf(r.Time)
+			return time.Unix(int64(sec), int64(math.Round(frac*1e9))).UTC().Format(o.timeFormat)
+		}
+		return formatFloat(&r.Time)
+	case CSVUpdateTime:
+		return formatFloat(&r.UpdateTime)
+	case CSVName:
+		return r.Name
+	case CSVUnit:
+		return r.Unit
+	case CSVValue:
+		return formatFloat(r.Value)
+	case CSVStringValue:
+		return r.StringValue
+	case CSVBoolValue:
+		if r.BoolValue != nil {
+			return fmt.Sprintf("%t", *r.BoolValue)
+		}
+	case CSVDataValue:
+		return r.DataValue
+	case CSVSum:
+		return formatFloat(r.Sum)
+	case CSVBaseName:
+		return r.BaseName
+	case CSVBaseTime:
+		return formatFloat(&r.BaseTime)
+	case CSVBaseUnit:
+		return r.BaseUnit
+	case CSVBaseVersion:
+		if r.BaseVersion != nil {
+			return strconv.Itoa(*r.BaseVersion)
+		}
+	case CSVBaseValue:
+		return formatFloat(r.BaseValue)
+	case CSVBaseSum:
+		return formatFloat(r.BaseSum)
+	}
+	return ""
+}
+
+// parseCSVField sets the field of the record from the value. Empty values are ignored.
+func parseCSVField(r *senml.Record, f CSVField, value string, o *codecOptions) error {
+	if value == "" {
+		return nil
+	}
+	parseFloat := func() (*float64, error) {
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, err
+		}
+		return &v, nil
+	}
+	switch f {
+	case CSVTime:
+		if o.timeFormat != "" {
+			t, err := time.Parse(o.timeFormat, value)
+			if err != nil {
+				return err
+			}
+			r.Time = float64(t.Unix()) + float64(t.Nanosecond())/1e9
+			return nil
+		}
+		v, err := parseFloat()
+		if err != nil {
+			return err
+		}
+		r.Time = *v
+	case CSVUpdateTime:
+		v, err := parseFloat()
+		if err != nil {
+			return err
+		}
+		r.UpdateTime = *v
+	case CSVName:
+		r.Name = value
+	case CSVUnit:
+		r.Unit = value
+	case CSVValue:
+		v, err := parseFloat()
+		if err != nil {
+			return err
+		}
+		r.Value = v
+	case CSVStringValue:
+		r.StringValue = value
+	case CSVBoolValue:
+		boolValue, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		r.BoolValue = &boolValue
+	case CSVDataValue:
+		r.DataValue = value
+	case CSVSum:
+		v, err := parseFloat()
+		if err != nil {
+			return err
+		}
+		r.Sum = v
+	case CSVBaseName:
+		r.BaseName = value
+	case CSVBaseTime:
+		v, err := parseFloat()
+		if err != nil {
+			return err
+		}
+		r.BaseTime = *v
+	case CSVBaseUnit:
+		r.BaseUnit = value
+	case CSVBaseVersion:
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		r.BaseVersion = &v
+	case CSVBaseValue:
+		v, err := parseFloat()
+		if err != nil {
+			return err
+		}
+		r.BaseValue = v
+	case CSVBaseSum:
+		v, err := parseFloat()
+		if err != nil {
+			return err
+		}
+		r.BaseSum = v
+	}
+	return nil
+}
+
+// WriteCSV serializes and writes the Pack on the given writer.
+// The pack is written in resolved form, unless the columns include base fields; see SetBaseFields.
+// The pack is not modified. Extensions are not written, and must-understand extensions are an error.
 func WriteCSV(p senml.Pack, w io.Writer, options ...Option) error {
+	if err := checkMustUnderstand(p); err != nil {
+		return err
+	}
 	o := &codecOptions{
-		header: false,
+		header:    false,
+		columns:   splitHeader(DefaultCSVHeader),
+		delimiter: ',',
 	}
 	for _, opt := range options {
 		opt(o)
 	}
 
+	columns, err := csvColumns(o.columns, o.mapping, true)
+	if err != nil {
+		return err
+	}
+
 	csvWriter := csv.NewWriter(w)
+	csvWriter.Comma = o.delimiter
 
 	if o.header {
-		err := csvWriter.Write(strings.Split(DefaultCSVHeader, ","))
+		err := csvWriter.Write(o.columns)
 		if err != nil {
 			return err
 		}
 	}
 
-	// normalize first to add base values to row values
-	p.Normalize()
+	// normalize first to add base values to row values, unless written separately
+	if !hasBaseCSVField(columns) {
+		p = p.Normalized()
+	}
 
 	for i := range p {
-		row := make([]string, 9)
-		row[0] = strconv.FormatFloat(p[i].Time, 'f', -1, 64)
-		row[1] = strconv.FormatFloat(p[i].UpdateTime, 'f', -1, 64)
-		row[2] = p[i].Name
-		row[3] = p[i].Unit
-		if p[i].Value != nil {
-			row[4] = strconv.FormatFloat(*p[i].Value, 'f', -1, 64)
-		}
-		row[5] = p[i].StringValue
-		if p[i].BoolValue != nil {
-			row[6] = fmt.Sprintf("%t", *p[i].BoolValue)
-		}
-		row[7] = p[i].DataValue
-		if p[i].Sum != nil {
-			row[8] = strconv.FormatFloat(*p[i].Sum, 'f', -1, 64)
+		row := make([]string, len(columns))
+		for j, f := range columns {
+			row[j] = formatCSVField(p[i], f, o)
 		}
 
 		err := csvWriter.Write(row)
@@ -79,15 +322,19 @@ func EncodeCSV(p senml.Pack, options ...Option) ([]byte, error) {
 // ReadCSV reads from the given reader to construct and returns a Pack
 func ReadCSV(r io.Reader, options ...Option) (senml.Pack, error) {
 	o := &codecOptions{
-		header: false,
+		header:    false,
+		columns:   splitHeader(DefaultCSVHeader),
+		delimiter: ',',
 	}
 	for _, opt := range options {
 		opt(o)
 	}
 
 	csvReader := csv.NewReader(r)
+	csvReader.Comma = o.delimiter
 
-	if o.header {
+	var columns []CSVField
+	if o.header || o.autoDetect {
 		row, err := csvReader.Read()
 		if err == io.EOF {
 			return nil, fmt.Errorf("missing header or no input")
@@ -95,8 +342,23 @@ func ReadCSV(r io.Reader, options ...Option) (senml.Pack, error) {
 		if err != nil {
 			return nil, err
 		}
-		if joined := strings.Join(row, ","); joined != DefaultCSVHeader {
-			return nil, fmt.Errorf("unexpected header: %s. Expected: %s", joined, DefaultCSVHeader)
+		if o.autoDetect {
+			columns, err = csvColumns(row, o.mapping, false)
+			if err != nil {
+				return nil, err
+			}
+			if !hasCSVField(columns) {
+				return nil, fmt.Errorf("no SenML columns in header: %s", strings.Join(row, ","))
+			}
+		} else if joined, expected := strings.Join(row, ","), strings.Join(o.columns, ","); joined != expected {
+			return nil, fmt.Errorf("unexpected header: %s. Expected: %s", joined, expected)
+		}
+	}
+	if columns == nil {
+		var err error
+		columns, err = csvColumns(o.columns, o.mapping, true)
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -109,49 +371,16 @@ func ReadCSV(r io.Reader, options ...Option) (senml.Pack, error) {
 		if err != nil {
 			return nil, err
 		}
+		if len(row) != len(columns) {
+			return nil, fmt.Errorf("wrong number of fields in row %d: %d. Expected: %d", len(p)+1, len(row), len(columns))
+		}
 
 		var record senml.Record
-		// Time
-		record.Time, err = strconv.ParseFloat(row[0], 10)
-		if err != nil {
-			return nil, err
-		}
-		// Update Time
-		record.UpdateTime, err = strconv.ParseFloat(row[1], 10)
-		if err != nil {
-			return nil, err
-		}
-		// Name
-		record.Name = row[2]
-		// Unit
-		record.Unit = row[3]
-		// Value
-		if row[4] != "" {
-			value, err := strconv.ParseFloat(row[4], 10)
-			if err != nil {
-				return nil, err
-			}
-			record.Value = &value
-		}
-		// String Value
-		record.StringValue = row[5]
-		// Boolean Value
-		if row[6] != "" {
-			boolValue, err := strconv.ParseBool(row[6])
+		for i, f := range columns {
+			err := parseCSVField(&record, f, row[i], o)
 			if err != nil {
-				return nil, err
-			}
-			record.BoolValue = &boolValue
-		}
-		// Data Value
-		record.DataValue = row[7]
-		// Sum
-		if row[8] != "" {
-			sum, err := strconv.ParseFloat(row[8], 10)
-			if err != nil {
-				return nil, err
+				return nil, fmt.Errorf("%s: %w", f, err)
 			}
-			record.Sum = &sum
 		}
 
 		p = append(p, record)
@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/farshidtz/senml/v2"
 )
@@ -75,6 +78,129 @@ func TestDecodeCSV(t *testing.T) {
 
 }
 
+func TestCSVBaseFields(t *testing.T) {
+	bver := 5
+	bvalue, bsum, value, sum := 10.0, 100.0, -0.5, 2.25
+	vb := false
+	p := senml.Pack{
+		{BaseName: "urn:dev:ow:10e2073a01080063:", BaseTime: 1320078429.125, BaseUnit: senml.UnitCelsius,
+			BaseVersion: &bver, BaseValue: &bvalue, BaseSum: &bsum,
+			Name: "temp", Unit: senml.UnitKelvin, Time: -1.5, UpdateTime: 60, Value: &value, Sum: &sum},
+		{Name: "label", StringValue: "north, \"wing\"", Time: 2},
+		{Name: "open", BoolValue: &vb},
+		{Name: "nfc", DataValue: "aGkgCg"},
+		{BaseName: "urn:dev:ow:10e2073a01080064:", BaseTime: -30, Name: "temp"},
+	}
+
+	dataOut, err := EncodeCSV(p, SetBaseFields, SetDefaultHeader)
+	if err != nil {
+		t.Fatalf("Encoding error: %s", err)
+	}
+	if header := strings.SplitN(string(dataOut), "\n", 2)[0]; header != BaseFieldsCSVHeader {
+		t.Fatalf("Unexpected header: %s", header)
+	}
+	pack, err := DecodeCSV(dataOut, SetBaseFields, SetDefaultHeader)
+	if err != nil {
+		t.Fatalf("Error decoding: %s", err)
+	}
+	if !reflect.DeepEqual(pack, p) {
+		t.Fatalf("Pack differs after round trip:\n%+v\n%+v", pack, p)
+	}
+
+	t.Run("custom header", func(t *testing.T) {
+		dataOut, err := EncodeCSV(p, SetHeader("bn,n,bver,v"))
+		if err != nil {
+			t.Fatalf("Encoding error: %s", err)
+		}
+		expected := "bn,n,bver,v\nurn:dev:ow:10e2073a01080063:,temp,5,-0.5\n,label,,\n,open,,\n,nfc,,\nurn:dev:ow:10e2073a01080064:,temp,,\n"
+		if string(dataOut) != expected {
+			t.Fatalf("Expected:\n'%s'\nGot:\n'%s'", expected, dataOut)
+		}
+	})
+}
+
+func TestWriteCSVClone(t *testing.T) {
+	p := referencePack()
+	_, err := EncodeCSV(p)
+	if err != nil {
+		t.Fatalf("Encoding error: %s", err)
+	}
+	if !reflect.DeepEqual(p, referencePack()) {
+		t.Fatalf("Pack was modified:\n%+v", p)
+	}
+}
+
+func TestCSVColumns(t *testing.T) {
+	value := 22.1
+	p := senml.Pack{
+		{BaseName: "dev123/", Name: "temp", Unit: senml.UnitCelsius, Value: &value, Time: 946684800.5},
+		{Name: "room", StringValue: "kitchen, north", Time: 946684801},
+	}
+
+	t.Run("custom header", func(t *testing.T) {
+		dataOut, err := EncodeCSV(p.Clone(), SetHeader("Name,Time,v,Unit"), SetDelimiter(';'), SetTimeFormat(time.RFC3339Nano))
+		if err != nil {
+			t.Fatalf("Encoding error: %s", err)
+		}
+		expected := "Name;Time;v;Unit\ndev123/temp;2000-01-01T00:00:00.5Z;22.1;Cel\ndev123/room;2000-01-01T00:00:01Z;;\n"
+		if string(dataOut) != expected {
+			t.Fatalf("Expected:\n'%s'\nGot:\n'%s'", expected, dataOut)
+		}
+
+		pack, err := DecodeCSV(dataOut, SetHeader("Name,Time,v,Unit"), SetDelimiter(';'), SetTimeFormat(time.RFC3339Nano))
+		if err != nil {
+			t.Fatalf("Error decoding: %s", err)
+		}
+		if len(pack) != 2 || pack[0].Name != "dev123/temp" || pack[0].Time != 946684800.5 || *pack[0].Value != 22.1 || pack[1].Unit != "" {
+			t.Fatalf("Unexpected records: %+v", pack)
+		}
+
+		_, err = DecodeCSV(dataOut, SetHeader("Name,Time,Value,Unit"), SetDelimiter(';'))
+		if err == nil {
+			t.Fatalf("No error for wrong header")
+		}
+	})
+
+	t.Run("unknown column", func(t *testing.T) {
+		if _, err := EncodeCSV(p.Clone(), SetHeader("Name,Temperature")); err == nil {
+			t.Fatalf("No error for unknown column")
+		}
+		if _, err := EncodeCSV(p.Clone(), SetHeader("Name,n")); err == nil {
+			t.Fatalf("No error for duplicate column")
+		}
+	})
+
+	t.Run("auto-detect with mapping", func(t *testing.T) {
+		input := "Sample,Timestamp,Channel,Reading,Unit,Operator\n" +
+			"1,2000-01-01T00:00:00Z,ch1,1.5,V,jane\n" +
+			"2,2000-01-01T00:01:00Z,ch2,,,jane\n"
+		pack, err := DecodeCSV([]byte(input), SetAutoDetectHeader, SetTimeFormat(time.RFC3339),
+			SetColumnMapping(map[string]CSVField{"Timestamp": CSVTime, "Channel": CSVName, "Reading": CSVValue}))
+		if err != nil {
+			t.Fatalf("Error decoding: %s", err)
+		}
+		if len(pack) != 2 || pack[0].Name != "ch1" || pack[0].Time != 946684800 || *pack[0].Value != 1.5 || pack[0].Unit != "V" {
+			t.Fatalf("Unexpected records: %+v", pack)
+		}
+		if pack[1].Time != 946684860 || pack[1].Value != nil {
+			t.Fatalf("Unexpected record: %+v", pack[1])
+		}
+
+		_, err = DecodeCSV([]byte(input), SetAutoDetectHeader)
+		if err != nil {
+			t.Fatalf("Error decoding without mapping: %s", err)
+		}
+		_, err = DecodeCSV([]byte("Sample,Operator\n1,jane\n"), SetAutoDetectHeader)
+		if err == nil {
+			t.Fatalf("No error for header without SenML columns")
+		}
+	})
+
+	if s := CSVBoolValue.String(); s != "Boolean Value" {
+		t.Fatalf("Unexpected field name: %s", s)
+	}
+}
+
 // EXAMPLES
 
 func ExampleEncodeCSV() {
@@ -0,0 +1,206 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/farshidtz/senml/v2"
+)
+
+// Wide CSV format: a row per time and a column per resolved name, after the time column.
+// The time column is named after CSVTime, unless mapped otherwise with SetColumnMapping.
+
+// formatWideValue returns the value of the record: the float value, string value, boolean value, data value or sum
+func formatWideValue(r senml.Record) string {
+	switch {
+	case r.Value != nil:
+		return strconv.FormatFloat(*r.Value, 'f', -1, 64)
+	case r.StringValue != "":
+		return r.StringValue
+	case r.BoolValue != nil:
+		return strconv.FormatBool(*r.BoolValue)
+	case r.DataValue != "":
+		return r.DataValue
+	case r.Sum != nil:
+		return strconv.FormatFloat(*r.Sum, 'f', -1, 64)
+	}
+	return ""
+}
+
+// parseWideValue sets the value of the record: a float value if numeric and finite, a boolean value if true or false,
+// otherwise a string value, e.g. for "nan" or "Infinity"
+func parseWideValue(r *senml.Record, value string) {
+	if v, err := strconv.ParseFloat(value, 64); err == nil && !math.IsNaN(v) && !math.IsInf(v, 0) {
+		r.Value = &v
+		return
+	}
+	switch value {
+	case "true", "false":
+		b := value == "true"
+		r.BoolValue = &b
+		return
+	}
+	r.StringValue = value
+}
+
+// splitAnnotation returns the name and unit of a column annotated as "name [unit]"
+func splitAnnotation(column string) (name, unit string) {
+	column = strings.TrimSpace(column)
+	if i := strings.LastIndex(column, " ["); i != -1 && strings.HasSuffix(column, "]") {
+		return column[:i], column[i+2 : len(column)-1]
+	}
+	return column, ""
+}
+
+// WriteWideCSV serializes the Pack in wide format, with a row per resolved time and a column per resolved name,
+// and writes it on the given writer. Rows are ordered by time and columns by the first record with the name.
+// The header is always written, with the units of the columns if SetUnitAnnotation is set.
+// Of the records with the same name and time, the last one is used. Records with the same name must have the same unit.
+//
+// Options: SetDelimiter, SetTimeFormat, SetMissingValue, SetUnitAnnotation.
+// The pack is not modified. Relative times are resolved using the current time.
+// Extensions are not written, and must-understand extensions are an error.
+func WriteWideCSV(p senml.Pack, w io.Writer, options ...Option) error {
+	if err := checkMustUnderstand(p); err != nil {
+		return err
+	}
+	o := &codecOptions{
+		delimiter: ',',
+	}
+	for _, opt := range options {
+		opt(o)
+	}
+
+	var names []string
+	units := make(map[string]string)
+	var times []float64
+	cells := make(map[float64]map[string]string)
+	for _, r := range p.Normalized() {
+		if unit, found := units[r.Name]; !found {
+			names = append(names, r.Name)
+			units[r.Name] = r.Unit
+		} else if unit != r.Unit {
+			return fmt.Errorf("different units for %s: %s and %s", r.Name, unit, r.Unit)
+		}
+		if cells[r.Time] == nil {
+			times = append(times, r.Time)
+			cells[r.Time] = make(map[string]string)
+		}
+		cells[r.Time][r.Name] = formatWideValue(r)
+	}
+	sort.Float64s(times)
+
+	csvWriter := csv.NewWriter(w)
+	csvWriter.Comma = o.delimiter
+
+	header := []string{CSVTime.String()}
+	for _, name := range names {
+		if o.annotate && units[name] != "" {
+			name += " [" + units[name] + "]"
+		}
+		header = append(header, name)
+	}
+	if err := csvWriter.Write(header); err != nil {
+		return err
+	}
+
+	for _, t := range times {
+		row := []string{formatCSVField(senml.Record{Time: t}, CSVTime, o)}
+		for _, name := range names {
+			value, found := cells[t][name]
+			if !found {
+				value = o.missing
+			}
+			row = append(row, value)
+		}
+		if err := csvWriter.Write(row); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// EncodeWideCSV serializes the SenML pack into CSV bytes in wide format; see WriteWideCSV
+func EncodeWideCSV(p senml.Pack, options ...Option) ([]byte, error) {
+	var buf bytes.Buffer
+	err := WriteWideCSV(p, &buf, options...)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ReadWideCSV reads CSV in wide format from the given reader and returns a Pack with a record per value,
+// in the order of rows and columns. Columns annotated with units, e.g. "room1/temp [Cel]", set the unit of their records.
+// Numeric values are decoded as float values, true and false as boolean values, and others as string values.
+// Empty fields and missing values are skipped.
+//
+// Options: SetDelimiter, SetTimeFormat, SetMissingValue, SetColumnMapping to map the time column.
+func ReadWideCSV(r io.Reader, options ...Option) (senml.Pack, error) {
+	o := &codecOptions{
+		delimiter: ',',
+	}
+	for _, opt := range options {
+		opt(o)
+	}
+
+	csvReader := csv.NewReader(r)
+	csvReader.Comma = o.delimiter
+
+	header, err := csvReader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("missing header or no input")
+	}
+	if err != nil {
+		return nil, err
+	}
+	timeColumn := -1
+	for i, column := range header {
+		if f, _ := csvFieldOf(strings.TrimSpace(column), o.mapping); f == CSVTime {
+			timeColumn = i
+			break
+		}
+	}
+	if timeColumn == -1 {
+		return nil, fmt.Errorf("missing time column in header: %s", strings.Join(header, ","))
+	}
+
+	var p senml.Pack
+	for {
+		row, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var timestamp senml.Record
+		if err := parseCSVField(&timestamp, CSVTime, row[timeColumn], o); err != nil {
+			return nil, fmt.Errorf("%s: %w", CSVTime, err)
+		}
+		for i, value := range row {
+			if i == timeColumn || value == "" || value == o.missing {
+				continue
+			}
+			name, unit := splitAnnotation(header[i])
+			record := senml.Record{Name: name, Unit: unit, Time: timestamp.Time}
+			parseWideValue(&record, value)
+			p = append(p, record)
+		}
+	}
+
+	return p, nil
+}
+
+// DecodeWideCSV takes a SenML pack in CSV bytes in wide format and decodes it into a Pack; see ReadWideCSV
+func DecodeWideCSV(b []byte, options ...Option) (senml.Pack, error) {
+	return ReadWideCSV(bytes.NewReader(b), options...)
+}
@@ -0,0 +1,89 @@
+package codec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/farshidtz/senml/v2"
+)
+
+func TestWideCSV(t *testing.T) {
+	float := func(f float64) *float64 { return &f }
+	vb := true
+	p := senml.Pack{
+		{BaseName: "room1/", BaseTime: 946684800, Name: "temp", Unit: senml.UnitCelsius, Value: float(22.1)},
+		{Name: "humidity", Unit: senml.UnitRelativeHumidity, Value: float(40)},
+		{Name: "temp", Unit: senml.UnitCelsius, Value: float(22.4), Time: 60},
+		{Name: "door", BoolValue: &vb, Time: 60},
+		{Name: "label", StringValue: "north wing", Time: -60},
+	}
+
+	t.Run("encode", func(t *testing.T) {
+		dataOut, err := EncodeWideCSV(p, SetMissingValue("NaN"), SetUnitAnnotation)
+		if err != nil {
+			t.Fatalf("Encoding error: %s", err)
+		}
+		expected := "Time,room1/temp [Cel],room1/humidity [%RH],room1/door,room1/label\n" +
+			"946684740,NaN,NaN,NaN,north wing\n" +
+			"946684800,22.1,40,NaN,NaN\n" +
+			"946684860,22.4,NaN,true,NaN\n"
+		if string(dataOut) != expected {
+			t.Fatalf("Expected:\n'%s'\nGot:\n'%s'", expected, dataOut)
+		}
+		if p[0].BaseName != "room1/" {
+			t.Fatalf("Pack was modified")
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		dataOut, err := EncodeWideCSV(p, SetUnitAnnotation, SetDelimiter(';'), SetTimeFormat(time.RFC3339))
+		if err != nil {
+			t.Fatalf("Encoding error: %s", err)
+		}
+		pack, err := DecodeWideCSV(dataOut, SetDelimiter(';'), SetTimeFormat(time.RFC3339))
+		if err != nil {
+			t.Fatalf("Error decoding: %s", err)
+		}
+		if len(senml.Diff(pack, p)) != 0 {
+			t.Fatalf("Unexpected records: %+v", pack)
+		}
+	})
+
+	t.Run("different units", func(t *testing.T) {
+		_, err := EncodeWideCSV(senml.Pack{{Name: "a", Unit: "m", Value: float(1)}, {Name: "a", Unit: "s", Value: float(1)}})
+		if err == nil {
+			t.Fatalf("No error for different units")
+		}
+	})
+
+	t.Run("decode with mapping", func(t *testing.T) {
+		input := "Timestamp\tch1 [V]\tch2\n946684800\t1.5\t-\n946684801\t-\tok\n"
+		pack, err := DecodeWideCSV([]byte(input), SetDelimiter('\t'), SetMissingValue("-"),
+			SetColumnMapping(map[string]CSVField{"Timestamp": CSVTime}))
+		if err != nil {
+			t.Fatalf("Error decoding: %s", err)
+		}
+		if len(pack) != 2 || pack[0].Name != "ch1" || pack[0].Unit != "V" || *pack[0].Value != 1.5 ||
+			pack[1].Name != "ch2" || pack[1].StringValue != "ok" || pack[1].Time != 946684801 {
+			t.Fatalf("Unexpected records: %+v", pack)
+		}
+
+		_, err = DecodeWideCSV([]byte(input), SetDelimiter('\t'))
+		if err == nil {
+			t.Fatalf("No error for missing time column")
+		}
+	})
+
+	t.Run("non-finite text", func(t *testing.T) {
+		pack, err := DecodeWideCSV([]byte("Time,status,b\n1276020076,nan,Infinity\n"))
+		if err != nil {
+			t.Fatalf("Error decoding: %s", err)
+		}
+		if len(pack) != 2 || pack[0].StringValue != "nan" || pack[1].StringValue != "Infinity" || pack[0].Value != nil || pack[1].Value != nil {
+			t.Fatalf("Unexpected records: %+v", pack)
+		}
+		if err := pack.Validate(); err != nil {
+			t.Fatalf("Error validating: %s", err)
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/farshidtz/senml/v2"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// SenML FETCH/PATCH encoding: https://tools.ietf.org/html/rfc8790
+// Both FETCH and PATCH packs use the etch media types. With the SetPatch option, records without a value or sum are
+// deletion records, encoded with a null value. Otherwise, they are FETCH selector records, encoded without a value.
+// The etch formats are decoded by DecodeJSON and DecodeCBOR, which decode null values as missing values.
+
+// EncodeEtchJSON serializes the SenML FETCH or PATCH pack into JSON bytes.
+// SetPatch encodes deletion records of a PATCH pack with null values. See EncodeJSON for the other options.
+func EncodeEtchJSON(p senml.Pack, options ...Option) ([]byte, error) {
+	o := &codecOptions{
+		prettyPrint: false,
+	}
+	for _, opt := range options {
+		opt(o)
+	}
+	if !o.patch {
+		return EncodeJSON(p, options...)
+	}
+
+	if o.prettyPrint {
+		var buf bytes.Buffer
+		buf.WriteString("[\n  ")
+		for i, r := range p {
+			if i != 0 {
+				buf.WriteString(",\n  ")
+			}
+			recData, err := json.Marshal(senml.EtchRecord(r))
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(recData)
+		}
+		buf.WriteString("\n]\n")
+		return buf.Bytes(), nil
+	}
+
+	return json.Marshal(etchRecords(p))
+}
+
+// EncodeEtchCBOR serializes the SenML FETCH or PATCH pack into CBOR bytes.
+// SetPatch encodes deletion records of a PATCH pack with null values. The other options are ignored.
+func EncodeEtchCBOR(p senml.Pack, options ...Option) ([]byte, error) {
+	o := &codecOptions{}
+	for _, opt := range options {
+		opt(o)
+	}
+	if !o.patch {
+		return EncodeCBOR(p)
+	}
+	return cbor.Marshal(etchRecords(p))
+}
+
+func etchRecords(p senml.Pack) []senml.EtchRecord {
+	records := make([]senml.EtchRecord, len(p))
+	for i := range p {
+		records[i] = senml.EtchRecord(p[i])
+	}
+	return records
+}
@@ -0,0 +1,102 @@
+package codec
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/farshidtz/senml/v2"
+)
+
+func TestEtch(t *testing.T) {
+	value := 22.5
+	patch := senml.Pack{
+		{BaseName: "urn:dev:ow:10e2073a01080063:", Name: "temp", Value: &value},
+		{Name: "humidity"},
+		{},
+	}
+
+	t.Run("json", func(t *testing.T) {
+		b, err := Encode(senml.MediaTypeSenmlEtchJSON, patch, SetPatch)
+		if err != nil {
+			t.Fatalf("Error encoding: %s", err)
+		}
+		expected := `[{"bn":"urn:dev:ow:10e2073a01080063:","n":"temp","v":22.5},{"n":"humidity","v":null},{"v":null}]`
+		if string(b) != expected {
+			t.Fatalf("Expected:\n%s\nGot:\n%s", expected, b)
+		}
+		decoded, err := Decode(senml.MediaTypeSenmlEtchJSON, b)
+		if err != nil {
+			t.Fatalf("Error decoding: %s", err)
+		}
+		if err := compareFields(decoded, patch); err != nil {
+			t.Fatalf("Error matching records: %s", err)
+		}
+	})
+
+	t.Run("json pretty", func(t *testing.T) {
+		b, err := EncodeEtchJSON(patch[1:2], SetPatch, SetPrettyPrint)
+		if err != nil {
+			t.Fatalf("Error encoding: %s", err)
+		}
+		if expected := "[\n  {\"n\":\"humidity\",\"v\":null}\n]\n"; string(b) != expected {
+			t.Fatalf("Expected:\n%s\nGot:\n%s", expected, b)
+		}
+	})
+
+	t.Run("cbor", func(t *testing.T) {
+		b, err := Encode(senml.MediaTypeSenmlEtchCBOR, patch[1:], SetPatch)
+		if err != nil {
+			t.Fatalf("Error encoding: %s", err)
+		}
+		// [{0: "humidity", 2: null}, {2: null}]
+		if expected := "82a2006868756d696469747902f6a102f6"; hex.EncodeToString(b) != expected {
+			t.Fatalf("Expected:\n%s\nGot:\n%x", expected, b)
+		}
+		decoded, err := Decode(senml.MediaTypeSenmlEtchCBOR, b)
+		if err != nil {
+			t.Fatalf("Error decoding: %s", err)
+		}
+		if err := compareFields(decoded, patch[1:]); err != nil {
+			t.Fatalf("Error matching records: %s", err)
+		}
+	})
+
+	t.Run("fetch", func(t *testing.T) {
+		selector := senml.Pack{{BaseName: "dev/", Name: "temp"}}
+		for mediaType, expected := range map[string]string{
+			senml.MediaTypeSenmlEtchJSON: `[{"bn":"dev/","n":"temp"}]`,
+			// [{-2: "dev/", 0: "temp"}]
+			senml.MediaTypeSenmlEtchCBOR: "81a221646465762f006474656d70",
+		} {
+			b, err := Encode(mediaType, selector)
+			if err != nil {
+				t.Fatalf("Error encoding: %s", err)
+			}
+			got := string(b)
+			if mediaType == senml.MediaTypeSenmlEtchCBOR {
+				got = hex.EncodeToString(b)
+			}
+			if got != expected {
+				t.Fatalf("Expected:\n%s\nGot:\n%s", expected, got)
+			}
+			decoded, err := Decode(mediaType, b)
+			if err != nil {
+				t.Fatalf("Error decoding: %s", err)
+			}
+			if err := compareFields(decoded, selector); err != nil {
+				t.Fatalf("Error matching records: %s", err)
+			}
+		}
+	})
+
+	t.Run("content formats", func(t *testing.T) {
+		for contentFormat, expected := range map[uint16]string{
+			senml.ContentFormatSenmlEtchJSON: senml.MediaTypeSenmlEtchJSON,
+			senml.ContentFormatSenmlEtchCBOR: senml.MediaTypeSenmlEtchCBOR,
+		} {
+			if mediaType, _ := DefaultRegistry.MediaType(contentFormat); mediaType != expected {
+				t.Fatalf("Expected %s for %d. Got: %s", expected, contentFormat, mediaType)
+			}
+		}
+	})
+}
@@ -0,0 +1,670 @@
+package codec
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+
+	"github.com/farshidtz/senml/v2"
+)
+
+// EXI (Efficient XML Interchange) representation of SenML:
+// https://tools.ietf.org/html/rfc8428#section-8
+//
+// Packs are encoded in the strict schema-informed mode of EXI with bit-packed alignment, using the SenML XML schema.
+// The EXI header contains the EXI options with the strict option and the schemaId of RFC8428.
+
+// exiSchemaID is the schemaId of the SenML XML schema in RFC8428
+const exiSchemaID = "a"
+
+type exiType int
+
+const (
+	exiString exiType = iota
+	exiDouble
+	exiInt
+	exiBoolean
+)
+
+// exiAttributes are the attributes of the senml element in the SenML XML schema of RFC8428, sorted by name as required by EXI.
+// The schema has no links attribute, so a senml element starts with one of 15 attributes or EE, taking 4 bits.
+var exiAttributes = []struct {
+	label string
+	kind  exiType
+}{
+	{"bn", exiString},
+	{"bs", exiDouble},
+	{"bt", exiDouble},
+	{"bu", exiString},
+	{"bv", exiDouble},
+	{"bver", exiInt},
+	{"n", exiString},
+	{"s", exiDouble},
+	{"t", exiDouble},
+	{"u", exiString},
+	{"ut", exiDouble},
+	{"v", exiDouble},
+	{"vb", exiBoolean},
+	{"vd", exiString},
+	{"vs", exiString},
+}
+
+// EncodeEXI serializes the SenML pack into EXI bytes. The options are ignored.
+// Extensions cannot be represented in EXI, and the pack must have at least one record as required by the schema.
+func EncodeEXI(p senml.Pack, _ ...Option) ([]byte, error) {
+	if len(p) == 0 {
+		return nil, fmt.Errorf("empty pack cannot be represented in EXI")
+	}
+	var w exiWriter
+	writeEXIHeader(&w)
+
+	values := newEXIStringTable()
+	// DocContent: SE(senml), SE(sensml), SE(*)
+	w.writeBits(1, 2)
+	for i := range p {
+		// sensml content: SE(senml) for the first record, then SE(senml), EE
+		if i > 0 {
+			w.writeBits(0, 1)
+		}
+		err := encodeEXIRecord(&w, &p[i], values)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %s", i, err)
+		}
+	}
+	w.writeBits(1, 1)
+	// DocEnd: ED
+	return w.bytes(), nil
+}
+
+// DecodeEXI takes a SenML pack in EXI bytes and decodes it into a Pack. The options are ignored.
+// Only streams in strict mode with the schemaId of RFC8428 are supported.
+func DecodeEXI(b []byte, _ ...Option) (senml.Pack, error) {
+	r := exiReader{data: b}
+	err := readEXIHeader(&r)
+	if err != nil {
+		return nil, err
+	}
+
+	values := newEXIStringTable()
+	// DocContent: SE(senml), SE(sensml), SE(*)
+	code, err := r.readBits(2)
+	if err != nil {
+		return nil, err
+	}
+	if code != 1 {
+		return nil, fmt.Errorf("unexpected EXI root element: expected sensml")
+	}
+
+	p := senml.Pack{}
+	for {
+		// sensml content: SE(senml) for the first record, then SE(senml), EE
+		if len(p) > 0 {
+			code, err := r.readBits(1)
+			if err != nil {
+				return nil, err
+			}
+			if code == 1 {
+				break
+			}
+		}
+		record, err := decodeEXIRecord(&r, values)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %s", len(p), err)
+		}
+		p = append(p, record)
+	}
+	// DocEnd: ED
+	return p, nil
+}
+
+// writeEXIHeader writes the EXI header with the options document <header><common><schemaId>a</schemaId></common><strict/></header>
+func writeEXIHeader(w *exiWriter) {
+	// distinguishing bits (10), presence of options (1), final version 1 (0 0000)
+	w.writeBits(0xa0, 8)
+
+	// the options document is encoded in strict mode using the EXI options schema
+	options := newEXIStringTable()
+	w.writeBits(0, 1) // DocContent: SE(header)
+	w.writeBits(1, 2) // header: SE(lesscommon), SE(common), SE(strict), EE
+	w.writeBits(2, 2) // common: SE(compression), SE(fragment), SE(schemaId), EE
+	w.writeBits(0, 1) // schemaId: CH, AT(xsi:nil)
+	options.write(w, "schemaId", exiSchemaID)
+	w.writeBits(0, 1) // header: SE(strict), EE
+}
+
+// readEXIHeader reads the EXI header and checks that the options are supported
+func readEXIHeader(r *exiReader) error {
+	// optional EXI cookie
+	if len(r.data) >= 4 && string(r.data[:4]) == "$EXI" {
+		r.pos = 32
+	}
+	head, err := r.readBits(8)
+	if err != nil {
+		return err
+	}
+	if head>>6 != 2 {
+		return fmt.Errorf("invalid EXI distinguishing bits")
+	}
+	if head&0x1f != 0 {
+		return fmt.Errorf("unsupported EXI version")
+	}
+	if head&0x20 == 0 {
+		return fmt.Errorf("unsupported EXI options: strict option is required")
+	}
+
+	options := newEXIStringTable()
+	// DocContent: SE(header), SE(*)
+	code, err := r.readBits(1)
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return fmt.Errorf("invalid EXI options")
+	}
+	// header: SE(lesscommon), SE(common), SE(strict), EE
+	code, err = r.readBits(2)
+	if err != nil {
+		return err
+	}
+	var schemaID string
+	if code == 0 {
+		return fmt.Errorf("unsupported EXI options: lesscommon")
+	}
+	if code == 1 {
+		// common: SE(compression), SE(fragment), SE(schemaId), EE
+		code, err = r.readBits(2)
+		if err != nil {
+			return err
+		}
+		switch code {
+		case 0:
+			return fmt.Errorf("unsupported EXI options: compression")
+		case 1:
+			return fmt.Errorf("unsupported EXI options: fragment")
+		case 2:
+			// schemaId: CH, AT(xsi:nil)
+			code, err = r.readBits(1)
+			if err != nil {
+				return err
+			}
+			if code != 0 {
+				return fmt.Errorf("unsupported EXI options: nil schemaId")
+			}
+			schemaID, err = options.read(r, "schemaId")
+			if err != nil {
+				return err
+			}
+		}
+		// header: SE(strict), EE
+		code, err = r.readBits(1)
+		if err != nil {
+			return err
+		}
+		code += 2
+	}
+	if code != 2 {
+		return fmt.Errorf("unsupported EXI options: strict option is required")
+	}
+	if schemaID != exiSchemaID {
+		return fmt.Errorf("unsupported EXI schemaId: %q", schemaID)
+	}
+	return nil
+}
+
+func encodeEXIRecord(w *exiWriter, r *senml.Record, values *exiStringTable) error {
+	for label := range r.Extensions {
+		return fmt.Errorf("extension cannot be represented in EXI: %s", label)
+	}
+
+	state := 0
+	for i, attr := range exiAttributes {
+		var value interface{}
+		switch attr.label {
+		case "bn":
+			value = r.BaseName
+		case "bs":
+			value = r.BaseSum
+		case "bt":
+			value = r.BaseTime
+		case "bu":
+			value = r.BaseUnit
+		case "bv":
+			value = r.BaseValue
+		case "bver":
+			value = r.BaseVersion
+		case "n":
+			value = r.Name
+		case "s":
+			value = r.Sum
+		case "t":
+			value = r.Time
+		case "u":
+			value = r.Unit
+		case "ut":
+			value = r.UpdateTime
+		case "v":
+			value = r.Value
+		case "vb":
+			value = r.BoolValue
+		case "vd":
+			value = r.DataValue
+		case "vs":
+			value = r.StringValue
+		}
+
+		switch v := value.(type) {
+		case string:
+			if v == "" {
+				continue
+			}
+			w.writeEventCode(i-state, len(exiAttributes)-state+1)
+			values.write(w, attr.label, v)
+		case float64:
+			if v == 0 {
+				continue
+			}
+			w.writeEventCode(i-state, len(exiAttributes)-state+1)
+			w.writeFloat(v)
+		case *float64:
+			if v == nil {
+				continue
+			}
+			w.writeEventCode(i-state, len(exiAttributes)-state+1)
+			w.writeFloat(*v)
+		case *int:
+			if v == nil {
+				continue
+			}
+			w.writeEventCode(i-state, len(exiAttributes)-state+1)
+			w.writeInteger(int64(*v))
+		case *bool:
+			if v == nil {
+				continue
+			}
+			w.writeEventCode(i-state, len(exiAttributes)-state+1)
+			if *v {
+				w.writeBits(1, 1)
+			} else {
+				w.writeBits(0, 1)
+			}
+		default:
+			continue
+		}
+		state = i + 1
+	}
+	// EE
+	w.writeEventCode(len(exiAttributes)-state, len(exiAttributes)-state+1)
+	return nil
+}
+
+func decodeEXIRecord(r *exiReader, values *exiStringTable) (senml.Record, error) {
+	var record senml.Record
+	state := 0
+	for state <= len(exiAttributes) {
+		code, err := r.readEventCode(len(exiAttributes) - state + 1)
+		if err != nil {
+			return record, err
+		}
+		if state+code == len(exiAttributes) {
+			// EE
+			break
+		}
+		attr := exiAttributes[state+code]
+		state += code + 1
+
+		var s string
+		var f float64
+		var i int64
+		var b bool
+		switch attr.kind {
+		case exiString:
+			s, err = values.read(r, attr.label)
+		case exiDouble:
+			f, err = r.readFloat()
+		case exiInt:
+			i, err = r.readInteger()
+			if err == nil && (i < math.MinInt32 || i > math.MaxInt32) {
+				err = fmt.Errorf("value out of range for %s: %d", attr.label, i)
+			}
+		case exiBoolean:
+			var bit uint64
+			bit, err = r.readBits(1)
+			b = bit == 1
+		}
+		if err != nil {
+			return record, err
+		}
+
+		switch attr.label {
+		case "bn":
+			record.BaseName = s
+		case "bs":
+			record.BaseSum = &f
+		case "bt":
+			record.BaseTime = f
+		case "bu":
+			record.BaseUnit = s
+		case "bv":
+			record.BaseValue = &f
+		case "bver":
+			bver := int(i)
+			record.BaseVersion = &bver
+		case "n":
+			record.Name = s
+		case "s":
+			record.Sum = &f
+		case "t":
+			record.Time = f
+		case "u":
+			record.Unit = s
+		case "ut":
+			record.UpdateTime = f
+		case "v":
+			record.Value = &f
+		case "vb":
+			record.BoolValue = &b
+		case "vd":
+			record.DataValue = s
+		case "vs":
+			record.StringValue = s
+		}
+	}
+	return record, nil
+}
+
+// exiStringTable holds the global and local value partitions of the EXI string table
+type exiStringTable struct {
+	global      map[string]int
+	globalCount int
+	local       map[string]map[string]int
+}
+
+func newEXIStringTable() *exiStringTable {
+	return &exiStringTable{
+		global: make(map[string]int),
+		local:  make(map[string]map[string]int),
+	}
+}
+
+func (t *exiStringTable) add(qname, value string) {
+	if value == "" {
+		return
+	}
+	t.global[value] = t.globalCount
+	t.globalCount++
+	if t.local[qname] == nil {
+		t.local[qname] = make(map[string]int)
+	}
+	t.local[qname][value] = len(t.local[qname])
+}
+
+// lookup returns the value with the given compact identifier in the global or local partition
+func (t *exiStringTable) lookup(partition map[string]int, id int) (string, bool) {
+	for value, i := range partition {
+		if i == id {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func (t *exiStringTable) write(w *exiWriter, qname, value string) {
+	if id, found := t.local[qname][value]; found {
+		w.writeUnsigned(0)
+		w.writeEventCode(id, len(t.local[qname]))
+		return
+	}
+	if id, found := t.global[value]; found {
+		w.writeUnsigned(1)
+		w.writeEventCode(id, t.globalCount)
+		return
+	}
+	w.writeUnsigned(uint64(len([]rune(value))) + 2)
+	for _, c := range value {
+		w.writeUnsigned(uint64(c))
+	}
+	t.add(qname, value)
+}
+
+func (t *exiStringTable) read(r *exiReader, qname string) (string, error) {
+	n, err := r.readUnsigned()
+	if err != nil {
+		return "", err
+	}
+	switch n {
+	case 0:
+		id, err := r.readEventCode(len(t.local[qname]))
+		if err != nil {
+			return "", err
+		}
+		value, found := t.lookup(t.local[qname], id)
+		if !found {
+			return "", fmt.Errorf("invalid EXI local value identifier: %d", id)
+		}
+		return value, nil
+	case 1:
+		id, err := r.readEventCode(t.globalCount)
+		if err != nil {
+			return "", err
+		}
+		value, found := t.lookup(t.global, id)
+		if !found {
+			return "", fmt.Errorf("invalid EXI global value identifier: %d", id)
+		}
+		return value, nil
+	}
+
+	length := n - 2
+	// each character takes at least one octet
+	if length > uint64(len(r.data)) {
+		return "", fmt.Errorf("invalid EXI string length: %d", length)
+	}
+	chars := make([]rune, length)
+	for i := range chars {
+		c, err := r.readUnsigned()
+		if err != nil {
+			return "", err
+		}
+		if c > math.MaxInt32 {
+			return "", fmt.Errorf("invalid EXI character: %d", c)
+		}
+		chars[i] = rune(c)
+	}
+	value := string(chars)
+	t.add(qname, value)
+	return value, nil
+}
+
+// exiWriter writes an EXI stream with bit-packed alignment
+type exiWriter struct {
+	data []byte
+	bits uint // number of bits written to the last byte
+}
+
+func (w *exiWriter) writeBits(v uint64, n int) {
+	for i := n - 1; i >= 0; i-- {
+		if w.bits == 0 {
+			w.data = append(w.data, 0)
+		}
+		if v>>uint(i)&1 == 1 {
+			w.data[len(w.data)-1] |= 0x80 >> w.bits
+		}
+		w.bits = (w.bits + 1) % 8
+	}
+}
+
+// writeEventCode writes the code as an n-bit unsigned integer for the given number of choices
+func (w *exiWriter) writeEventCode(code, choices int) {
+	w.writeBits(uint64(code), codeLength(choices))
+}
+
+func (w *exiWriter) writeUnsigned(v uint64) {
+	for {
+		b := v & 0x7f
+		v >>= 7
+		if v != 0 {
+			b |= 0x80
+		}
+		w.writeBits(b, 8)
+		if v == 0 {
+			return
+		}
+	}
+}
+
+func (w *exiWriter) writeInteger(v int64) {
+	if v < 0 {
+		w.writeBits(1, 1)
+		w.writeUnsigned(uint64(-(v + 1)))
+		return
+	}
+	w.writeBits(0, 1)
+	w.writeUnsigned(uint64(v))
+}
+
+// writeFloat writes the float as a decimal mantissa and exponent
+func (w *exiWriter) writeFloat(f float64) {
+	switch {
+	case math.IsInf(f, 1):
+		w.writeInteger(1)
+		w.writeInteger(exiSpecialExponent)
+	case math.IsInf(f, -1):
+		w.writeInteger(-1)
+		w.writeInteger(exiSpecialExponent)
+	case math.IsNaN(f):
+		w.writeInteger(0)
+		w.writeInteger(exiSpecialExponent)
+	default:
+		mantissa, exponent := decimal(f)
+		w.writeInteger(mantissa)
+		w.writeInteger(exponent)
+	}
+}
+
+func (w *exiWriter) bytes() []byte {
+	return w.data
+}
+
+// exiSpecialExponent is the exponent of the special float values INF, -INF and NaN
+const exiSpecialExponent = -(1 << 14)
+
+// decimal returns the shortest decimal mantissa and exponent which represent the float
+func decimal(f float64) (mantissa, exponent int64) {
+	s := strconv.FormatFloat(f, 'e', -1, 64) // e.g. -1.201e+02
+	var digits []byte
+	i := 0
+	for ; s[i] != 'e'; i++ {
+		if s[i] != '.' {
+			digits = append(digits, s[i])
+		}
+	}
+	mantissa, _ = strconv.ParseInt(string(digits), 10, 64)
+	exponent, _ = strconv.ParseInt(s[i+1:], 10, 64)
+	if mantissa != 0 {
+		exponent -= int64(len(digits) - 1)
+		if mantissa < 0 {
+			exponent++ // the sign is not a digit
+		}
+	}
+	return mantissa, exponent
+}
+
+// exiReader reads an EXI stream with bit-packed alignment
+type exiReader struct {
+	data []byte
+	pos  int // position in bits
+}
+
+func (r *exiReader) readBits(n int) (uint64, error) {
+	if r.pos+n > len(r.data)*8 {
+		return 0, fmt.Errorf("unexpected end of EXI stream")
+	}
+	var v uint64
+	for i := 0; i < n; i++ {
+		bit := r.data[r.pos/8] >> (7 - uint(r.pos%8)) & 1
+		v = v<<1 | uint64(bit)
+		r.pos++
+	}
+	return v, nil
+}
+
+// readEventCode reads an n-bit unsigned integer for the given number of choices
+func (r *exiReader) readEventCode(choices int) (int, error) {
+	code, err := r.readBits(codeLength(choices))
+	if err != nil {
+		return 0, err
+	}
+	if int(code) >= choices {
+		return 0, fmt.Errorf("invalid EXI event code: %d", code)
+	}
+	return int(code), nil
+}
+
+func (r *exiReader) readUnsigned() (uint64, error) {
+	var v uint64
+	for shift := uint(0); ; shift += 7 {
+		if shift > 63 {
+			return 0, fmt.Errorf("EXI unsigned integer overflow")
+		}
+		b, err := r.readBits(8)
+		if err != nil {
+			return 0, err
+		}
+		v |= (b & 0x7f) << shift
+		if b&0x80 == 0 {
+			return v, nil
+		}
+	}
+}
+
+func (r *exiReader) readInteger() (int64, error) {
+	sign, err := r.readBits(1)
+	if err != nil {
+		return 0, err
+	}
+	v, err := r.readUnsigned()
+	if err != nil {
+		return 0, err
+	}
+	if v > math.MaxInt64 {
+		return 0, fmt.Errorf("EXI integer overflow")
+	}
+	if sign == 1 {
+		return -int64(v) - 1, nil
+	}
+	return int64(v), nil
+}
+
+func (r *exiReader) readFloat() (float64, error) {
+	mantissa, err := r.readInteger()
+	if err != nil {
+		return 0, err
+	}
+	exponent, err := r.readInteger()
+	if err != nil {
+		return 0, err
+	}
+	if exponent == exiSpecialExponent {
+		switch mantissa {
+		case 1:
+			return math.Inf(1), nil
+		case -1:
+			return math.Inf(-1), nil
+		default:
+			return math.NaN(), nil
+		}
+	}
+	if exponent < exiSpecialExponent || exponent > -exiSpecialExponent {
+		return 0, fmt.Errorf("EXI float exponent out of range: %d", exponent)
+	}
+	return strconv.ParseFloat(fmt.Sprintf("%de%d", mantissa, exponent), 64)
+}
+
+// codeLength returns the number of bits needed to encode the given number of choices
+func codeLength(choices int) int {
+	if choices <= 1 {
+		return 0
+	}
+	return bits.Len(uint(choices - 1))
+}
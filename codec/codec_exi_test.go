@@ -0,0 +1,200 @@
+package codec
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/farshidtz/senml/v2"
+)
+
+const (
+	// EXI for [{"n":"a","v":1}]
+	exiHexBytesString = "a0300d84b01b0a00401c"
+	// EXI for the example of RFC8428 section 5.1.2: https://tools.ietf.org/html/rfc8428#section-8
+	exiHexRFC8428String = "a0300d8480f3ab9371d3232bb1d37bb9d18983299181b99b09818981c18181b199d284bb37b63a30b3b2901ab15884c03304b1bab93932b73a101a09064038"
+)
+
+// exiRFC8428Pack is the example of RFC8428 section 5.1.2
+func exiRFC8428Pack() senml.Pack {
+	voltage, current := 120.1, 1.2
+	return senml.Pack{
+		{BaseName: "urn:dev:ow:10e2073a01080063:", Name: "voltage", Unit: "V", Value: &voltage},
+		{Name: "current", Unit: "A", Value: &current},
+	}
+}
+
+func TestEncodeEXI(t *testing.T) {
+
+	t.Run("single record", func(t *testing.T) {
+		v := 1.0
+		dataOut, err := EncodeEXI(senml.Pack{{Name: "a", Value: &v}})
+		if err != nil {
+			t.Fatalf("Encoding error: %s", err)
+		}
+		dataOutHex := hex.EncodeToString(dataOut)
+
+		if dataOutHex != exiHexBytesString {
+			t.Logf("Expected (hex):\n%v", exiHexBytesString)
+			t.Fatalf("Got (hex):\n%v", dataOutHex)
+		}
+	})
+
+	t.Run("RFC8428 example", func(t *testing.T) {
+		dataOut, err := EncodeEXI(exiRFC8428Pack())
+		if err != nil {
+			t.Fatalf("Encoding error: %s", err)
+		}
+		if dataOutHex := hex.EncodeToString(dataOut); dataOutHex != exiHexRFC8428String {
+			t.Logf("Expected (hex):\n%v", exiHexRFC8428String)
+			t.Fatalf("Got (hex):\n%v", dataOutHex)
+		}
+	})
+
+	t.Run("unsupported extension", func(t *testing.T) {
+		v := 1.0
+		for _, label := range []string{"label", "l"} {
+			_, err := EncodeEXI(senml.Pack{{Name: "a", Value: &v, Extensions: map[string]interface{}{label: "kitchen"}}})
+			if err == nil {
+				t.Fatalf("No error for extension %s", label)
+			}
+		}
+	})
+
+	t.Run("empty pack", func(t *testing.T) {
+		_, err := EncodeEXI(senml.Pack{})
+		if err == nil {
+			t.Fatalf("No error for empty pack")
+		}
+	})
+}
+
+func TestDecodeEXI(t *testing.T) {
+
+	t.Run("single record", func(t *testing.T) {
+		exiBytes, err := hex.DecodeString(exiHexBytesString)
+		if err != nil {
+			t.Fatalf("Error decoding test value: %s", err)
+		}
+
+		pack, err := DecodeEXI(exiBytes)
+		if err != nil {
+			t.Fatalf("Error decoding: %s", err)
+		}
+		if len(pack) != 1 || pack[0].Name != "a" || pack[0].Value == nil || *pack[0].Value != 1 {
+			t.Fatalf("Unexpected pack: %v", pack)
+		}
+	})
+
+	t.Run("RFC8428 example", func(t *testing.T) {
+		exiBytes, err := hex.DecodeString(exiHexRFC8428String)
+		if err != nil {
+			t.Fatalf("Error decoding test value: %s", err)
+		}
+
+		pack, err := DecodeEXI(exiBytes)
+		if err != nil {
+			t.Fatalf("Error decoding: %s", err)
+		}
+		if err := compareFields(pack, exiRFC8428Pack()); err != nil {
+			t.Fatalf("Error matching records: %s", err)
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		dataOut, err := EncodeEXI(referencePack())
+		if err != nil {
+			t.Fatalf("Encoding error: %s", err)
+		}
+		pack, err := DecodeEXI(dataOut)
+		if err != nil {
+			t.Fatalf("Error decoding: %s", err)
+		}
+		if err := compareFields(pack, referencePack()); err != nil {
+			t.Fatalf("Error matching records: %s", err)
+		}
+	})
+
+	t.Run("string table", func(t *testing.T) {
+		v := 1.0
+		p := senml.Pack{
+			{Name: "temp", Unit: "Cel", Value: &v},
+			{Name: "temp", Unit: "Cel", Value: &v},
+			{Name: "Cel", StringValue: "", Value: &v},
+			{Name: "", StringValue: "temp"},
+		}
+		dataOut, err := EncodeEXI(p)
+		if err != nil {
+			t.Fatalf("Encoding error: %s", err)
+		}
+		pack, err := DecodeEXI(dataOut)
+		if err != nil {
+			t.Fatalf("Error decoding: %s", err)
+		}
+		if err := compareFields(pack, p); err != nil {
+			t.Fatalf("Error matching records: %s", err)
+		}
+	})
+
+	t.Run("special floats", func(t *testing.T) {
+		for _, f := range []float64{0.1, -120.1, 1e-300, -math.MaxFloat64, math.SmallestNonzeroFloat64, math.Inf(1), math.Inf(-1)} {
+			v := f
+			dataOut, err := EncodeEXI(senml.Pack{{Name: "a", Value: &v}})
+			if err != nil {
+				t.Fatalf("Encoding error: %s", err)
+			}
+			pack, err := DecodeEXI(dataOut)
+			if err != nil {
+				t.Fatalf("Error decoding: %s", err)
+			}
+			if *pack[0].Value != f {
+				t.Fatalf("Expected %v. Got: %v", f, *pack[0].Value)
+			}
+		}
+	})
+
+	t.Run("EXI cookie", func(t *testing.T) {
+		exiBytes, _ := hex.DecodeString(exiHexBytesString)
+		pack, err := DecodeEXI(append([]byte("$EXI"), exiBytes...))
+		if err != nil {
+			t.Fatalf("Error decoding: %s", err)
+		}
+		if len(pack) != 1 {
+			t.Fatalf("Unexpected pack: %v", pack)
+		}
+	})
+
+	t.Run("truncated stream", func(t *testing.T) {
+		exiBytes, _ := hex.DecodeString(exiHexBytesString)
+		for i := range exiBytes {
+			_, err := DecodeEXI(exiBytes[:i])
+			if err == nil {
+				t.Fatalf("No error for stream truncated to %d bytes", i)
+			}
+		}
+	})
+
+	t.Run("missing options", func(t *testing.T) {
+		_, err := DecodeEXI([]byte{0x80, 0x40})
+		if err == nil {
+			t.Fatalf("No error for non-strict stream")
+		}
+	})
+}
+
+// EXAMPLES
+
+func ExampleEncodeEXI() {
+	v := 1.0
+	var p senml.Pack = []senml.Record{
+		{Value: &v, Name: "a"},
+	}
+
+	dataOut, err := EncodeEXI(p)
+	if err != nil {
+		panic(err) // handle the error
+	}
+	fmt.Printf("%x", dataOut)
+	// Output: a0300d84b01b0a00401c
+}
@@ -1,13 +1,23 @@
 package codec
 
 import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
 	senmlprotobuf "github.com/farshidtz/senml-protobuf/go"
 	"github.com/farshidtz/senml/v2"
 	"github.com/golang/protobuf/proto"
 )
 
 // EncodeProtobuf serializes the SenML pack into Protobuf bytes. The options are ignored.
+// Extensions are not encoded, and must-understand extensions are an error.
 func EncodeProtobuf(p senml.Pack, _ ...Option) ([]byte, error) {
+	if err := checkMustUnderstand(p); err != nil {
+		return nil, err
+	}
 	message := ExportProtobufMessage(p)
 	return proto.Marshal(&message)
 }
@@ -23,6 +33,75 @@ func DecodeProtobuf(b []byte, _ ...Option) (senml.Pack, error) {
 	return ImportProtobufMessage(message), nil
 }
 
+// MaxProtobufFrameSize is the largest length-delimited Protobuf frame accepted by ProtobufFrameReader
+const MaxProtobufFrameSize = 64 << 20
+
+// WriteProtobuf serializes the SenML pack into a length-delimited Protobuf frame and writes it on the given writer.
+// The frame is the length of the message as a varint, followed by the message.
+// Multiple packs can be written to the same writer, and read back with ReadProtobuf or ProtobufFrameReader. The options are ignored.
+func WriteProtobuf(p senml.Pack, w io.Writer, _ ...Option) error {
+	b, err := EncodeProtobuf(p)
+	if err != nil {
+		return err
+	}
+	head := make([]byte, binary.MaxVarintLen64)
+	_, err = w.Write(head[:binary.PutUvarint(head, uint64(len(b)))])
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// ReadProtobuf reads length-delimited Protobuf frames from the given reader until its end,
+// and returns a Pack with the records of all frames. Each frame is resolved on its own, so that its base fields
+// do not apply to the records of the following frames. Relative times are kept. The options are ignored.
+func ReadProtobuf(r io.Reader, _ ...Option) (senml.Pack, error) {
+	frames := NewProtobufFrameReader(r)
+	var p senml.Pack
+	for {
+		frame, err := frames.Next()
+		if err == io.EOF {
+			return p, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		p = append(p, frame.Normalized(senml.SetKeepRelativeTime)...)
+	}
+}
+
+// ProtobufFrameReader reads length-delimited Protobuf frames, as written by WriteProtobuf, one at a time
+type ProtobufFrameReader struct {
+	r *bufio.Reader
+}
+
+// NewProtobufFrameReader returns a reader of the frames from r
+func NewProtobufFrameReader(r io.Reader) *ProtobufFrameReader {
+	return &ProtobufFrameReader{r: bufio.NewReader(r)}
+}
+
+// Next reads the next frame and returns its pack. It returns io.EOF at the end of input between frames,
+// and io.ErrUnexpectedEOF at the end of input within a frame.
+func (fr *ProtobufFrameReader) Next() (senml.Pack, error) {
+	length, err := binary.ReadUvarint(fr.r)
+	if err != nil {
+		return nil, err
+	}
+	if length > MaxProtobufFrameSize {
+		return nil, fmt.Errorf("protobuf frame too large: %d bytes", length)
+	}
+	b := make([]byte, length)
+	_, err = io.ReadFull(fr.r, b)
+	if errors.Is(err, io.EOF) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	return DecodeProtobuf(b)
+}
+
 // ExportProtobufMessage converts senml.Pack to senmlprotobuf.Message
 func ExportProtobufMessage(p senml.Pack) senmlprotobuf.Message {
 	var message senmlprotobuf.Message
@@ -41,11 +120,11 @@ func ExportProtobufMessage(p senml.Pack) senmlprotobuf.Message {
 		}
 		// BaseValue
 		if p[i].BaseValue != nil {
-			r.BaseValueOptional = &senmlprotobuf.Record_BaseValue{*p[i].BaseValue}
+			r.BaseValueOptional = &senmlprotobuf.Record_BaseValue{BaseValue: *p[i].BaseValue}
 		}
 		// BaseSum
 		if p[i].BaseSum != nil {
-			r.BaseSumOptional = &senmlprotobuf.Record_BaseSum{*p[i].BaseSum}
+			r.BaseSumOptional = &senmlprotobuf.Record_BaseSum{BaseSum: *p[i].BaseSum}
 		}
 		//
 		// Name
@@ -59,17 +138,17 @@ func ExportProtobufMessage(p senml.Pack) senmlprotobuf.Message {
 		//
 		// Value, BoolValue, StringValue, DataValue
 		if p[i].Value != nil {
-			r.ValueOneof = &senmlprotobuf.Record_Value{*p[i].Value}
+			r.ValueOneof = &senmlprotobuf.Record_Value{Value: *p[i].Value}
 		} else if p[i].BoolValue != nil {
-			r.ValueOneof = &senmlprotobuf.Record_BoolValue{*p[i].BoolValue}
+			r.ValueOneof = &senmlprotobuf.Record_BoolValue{BoolValue: *p[i].BoolValue}
 		} else if p[i].StringValue != "" {
-			r.ValueOneof = &senmlprotobuf.Record_StringValue{p[i].StringValue}
+			r.ValueOneof = &senmlprotobuf.Record_StringValue{StringValue: p[i].StringValue}
 		} else if p[i].DataValue != "" {
-			r.ValueOneof = &senmlprotobuf.Record_DataValue{p[i].DataValue}
+			r.ValueOneof = &senmlprotobuf.Record_DataValue{DataValue: p[i].DataValue}
 		}
 		// Sum
 		if p[i].Sum != nil {
-			r.SumOptional = &senmlprotobuf.Record_Sum{*p[i].Sum}
+			r.SumOptional = &senmlprotobuf.Record_Sum{Sum: *p[i].Sum}
 		}
 		message.Pack[i] = &r
 	}
@@ -1,8 +1,10 @@
 package codec
 
 import (
+	"bytes"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"testing"
 
@@ -58,6 +60,99 @@ func TestDecodeProtobuf(t *testing.T) {
 
 }
 
+func TestProtobufFrames(t *testing.T) {
+	value := 22.1
+	second := senml.Pack{{Name: "dev123/temp", Value: &value, Time: 946684800}}
+
+	var buf bytes.Buffer
+	for _, p := range []senml.Pack{referencePack(), second} {
+		if err := WriteProtobuf(p, &buf); err != nil {
+			t.Fatalf("Error writing: %s", err)
+		}
+	}
+	data := buf.Bytes()
+	// the length of the first message fits in a single byte varint
+	if length := int(data[0]); length != len(protobufHexBytesString)/2 {
+		t.Fatalf("Unexpected frame length: %d", length)
+	}
+
+	t.Run("frames", func(t *testing.T) {
+		frames := NewProtobufFrameReader(bytes.NewReader(data))
+		for i, expected := range []senml.Pack{referencePack(), second} {
+			p, err := frames.Next()
+			if err != nil {
+				t.Fatalf("Error reading frame %d: %s", i, err)
+			}
+			if err := compareFields(p, expected); err != nil {
+				t.Fatalf("Error matching records of frame %d: %s", i, err)
+			}
+		}
+		if _, err := frames.Next(); err != io.EOF {
+			t.Fatalf("Expected EOF. Got: %v", err)
+		}
+	})
+
+	t.Run("read all", func(t *testing.T) {
+		p, err := ReadProtobuf(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("Error reading: %s", err)
+		}
+		expected := append(referencePack().Normalized(senml.SetKeepRelativeTime), second...)
+		if err := compareFields(p, expected); err != nil {
+			t.Fatalf("Error matching records: %s", err)
+		}
+	})
+
+	t.Run("base fields of frames", func(t *testing.T) {
+		bver := 11
+		var buf bytes.Buffer
+		for _, p := range []senml.Pack{
+			{{BaseName: "a:", Name: "x", Value: &value}},
+			{{BaseVersion: &bver, Name: "y", Value: &value}},
+		} {
+			if err := WriteProtobuf(p, &buf); err != nil {
+				t.Fatalf("Error writing: %s", err)
+			}
+		}
+		p, err := ReadProtobuf(&buf)
+		if err != nil {
+			t.Fatalf("Error reading: %s", err)
+		}
+		if len(p) != 2 || p[0].Name != "a:x" || p[1].Name != "y" {
+			t.Fatalf("Unexpected names: %v", p)
+		}
+		if p[0].BaseVersion != nil || p[1].BaseVersion == nil || *p[1].BaseVersion != bver {
+			t.Fatalf("Unexpected base versions: %v", p)
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		_, err := ReadProtobuf(bytes.NewReader(data[:len(data)-1]))
+		if err != io.ErrUnexpectedEOF {
+			t.Fatalf("Expected unexpected EOF. Got: %v", err)
+		}
+		_, err = ReadProtobuf(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff, 0x7f}))
+		if err == nil {
+			t.Fatalf("No error for frame too large")
+		}
+	})
+
+	t.Run("registry", func(t *testing.T) {
+		dataOut, err := Encode(senml.MediaTypeCustomSenmlProtobuf, referencePack())
+		if err != nil || hex.EncodeToString(dataOut) != protobufHexBytesString {
+			t.Fatalf("Unexpected encoding: %x, %v", dataOut, err)
+		}
+		p, err := Decode(senml.MediaTypeCustomSensmlProtobuf, data)
+		if err != nil || len(p) != len(referencePack())+1 {
+			t.Fatalf("Unexpected decoding: %v, %v", p, err)
+		}
+		dataOut, err = Encode(senml.MediaTypeCustomSensmlProtobuf, referencePack())
+		if err != nil || !bytes.HasPrefix(data, dataOut) {
+			t.Fatalf("Unexpected stream encoding: %x, %v", dataOut, err)
+		}
+	})
+}
+
 // EXAMPLES
 
 func ExampleEncodeProtobuf() {
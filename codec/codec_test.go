@@ -1,7 +1,10 @@
 package codec
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
+	"testing"
 
 	"github.com/farshidtz/senml/v2"
 )
@@ -122,3 +125,60 @@ func ExampleDecode() {
 		panic(err) // handle the error
 	}
 }
+
+func TestExtensions(t *testing.T) {
+	senml.RegisterMustUnderstand("battery_")
+
+	value := 22.1
+	p := senml.Pack{
+		{Name: "dev", Value: &value, Extensions: map[string]interface{}{"battery_": "87", "label": "kitchen"}},
+	}
+
+	codecs := map[string]struct {
+		encode Encoder
+		decode Decoder
+	}{
+		"JSON": {EncodeJSON, DecodeJSON},
+		"CBOR": {EncodeCBOR, DecodeCBOR},
+		"XML":  {EncodeXML, DecodeXML},
+	}
+	for name, c := range codecs {
+		t.Run(name, func(t *testing.T) {
+			b, err := c.encode(p)
+			if err != nil {
+				t.Fatalf("Encoding error: %s", err)
+			}
+			decoded, err := c.decode(b)
+			if err != nil {
+				t.Fatalf("Error decoding: %s", err)
+			}
+			if !reflect.DeepEqual(decoded[0].Extensions, p[0].Extensions) {
+				t.Fatalf("Expected extensions: %v. Got: %v", p[0].Extensions, decoded[0].Extensions)
+			}
+
+			unknown := p.Clone()
+			unknown[0].Extensions["unknown_"] = "1"
+			_, err = c.encode(unknown)
+			if !errors.Is(err, senml.ErrMustUnderstand) {
+				t.Fatalf("Expected must-understand error. Got: %v", err)
+			}
+		})
+	}
+}
+
+func TestExtensionsNotEncoded(t *testing.T) {
+	value := 22.1
+	p := senml.Pack{{Name: "dev", Value: &value, Extensions: map[string]interface{}{"label": "kitchen"}}}
+	mustUnderstand := senml.Pack{{Name: "dev", Value: &value, Extensions: map[string]interface{}{"battery_": "87"}}}
+
+	for name, encode := range map[string]Encoder{"CSV": EncodeCSV, "wide CSV": EncodeWideCSV, "Protobuf": EncodeProtobuf} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := encode(p); err != nil {
+				t.Fatalf("Encoding error: %s", err)
+			}
+			if _, err := encode(mustUnderstand); !errors.Is(err, senml.ErrMustUnderstand) {
+				t.Fatalf("Expected must-understand error. Got: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// Package httpsenml provides an HTTP handler and client helpers for exchanging SenML Packs:
+// http://github.com/farshidtz/senml
+package httpsenml
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/farshidtz/senml/v2"
+	"github.com/farshidtz/senml/v2/codec"
+)
+
+// DefaultMaxBodySize is the default limit for the size of request bodies in bytes
+const DefaultMaxBodySize = 1 << 20
+
+// PackHandler is the function type for handling a decoded and validated Pack.
+// The response is written by the handler. If nothing is written, the response is 200 OK.
+type PackHandler func(w http.ResponseWriter, r *http.Request, p senml.Pack)
+
+type handlerOptions struct {
+	normalize   bool
+	maxBodySize int64
+	registry    *codec.Registry
+}
+
+// Option is the function type for setting handler options
+type Option func(*handlerOptions)
+
+// SetNormalize enables normalization of the Pack after validation
+func SetNormalize(o *handlerOptions) {
+	o.normalize = true
+}
+
+// SetMaxBodySize sets the limit for the size of request bodies in bytes. Larger bodies are rejected with 413 Request Entity Too Large.
+func SetMaxBodySize(size int64) Option {
+	return func(o *handlerOptions) {
+		o.maxBodySize = size
+	}
+}
+
+// SetRegistry sets the registry for looking up the decoders. The default is codec.DefaultRegistry.
+func SetRegistry(reg *codec.Registry) Option {
+	return func(o *handlerOptions) {
+		o.registry = reg
+	}
+}
+
+type contextKey struct{}
+
+// PackFromContext returns the Pack stored in the request context by the Middleware
+func PackFromContext(ctx context.Context) (senml.Pack, bool) {
+	p, ok := ctx.Value(contextKey{}).(senml.Pack)
+	return p, ok
+}
+
+// Middleware returns a middleware that decodes the request body according to its Content-Type, validates it,
+// and passes the Pack to the next handler in the request context. See PackFromContext.
+// It responds with 415 Unsupported Media Type for unsupported content types, 413 Request Entity Too Large for bodies exceeding
+// the size limit, and 400 Bad Request for bodies which cannot be decoded or are not valid.
+func Middleware(options ...Option) func(http.Handler) http.Handler {
+	o := &handlerOptions{
+		normalize:   false,
+		maxBodySize: DefaultMaxBodySize,
+		registry:    codec.DefaultRegistry,
+	}
+	for _, opt := range options {
+		opt(o)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			contentType := r.Header.Get("Content-Type")
+			decode, err := o.registry.DecoderForContentType(contentType)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+				return
+			}
+
+			if r.ContentLength > o.maxBodySize {
+				http.Error(w, fmt.Sprintf("request body larger than %d bytes", o.maxBodySize), http.StatusRequestEntityTooLarge)
+				return
+			}
+			b, err := ioutil.ReadAll(io.LimitReader(r.Body, o.maxBodySize+1))
+			if err != nil {
+				http.Error(w, fmt.Sprintf("error reading request body: %s", err), http.StatusBadRequest)
+				return
+			}
+			if int64(len(b)) > o.maxBodySize {
+				http.Error(w, fmt.Sprintf("request body larger than %d bytes", o.maxBodySize), http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			p, err := decode(b)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("error decoding %s: %s", contentType, err), http.StatusBadRequest)
+				return
+			}
+			err = p.Validate()
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid pack: %s", err), http.StatusBadRequest)
+				return
+			}
+			if o.normalize {
+				p.Normalize()
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, p)))
+		})
+	}
+}
+
+// NewHandler returns a handler that decodes and validates the request body and passes the Pack to the given function.
+// It responds with errors in the same way as the Middleware.
+func NewHandler(handle PackHandler, options ...Option) http.Handler {
+	return Middleware(options...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, _ := PackFromContext(r.Context())
+		handle(w, r, p)
+	}))
+}
+
+// NewRequest returns a request with the Pack encoded in the given media type as the body
+func NewRequest(method, url, mediaType string, p senml.Pack, options ...codec.Option) (*http.Request, error) {
+	b, err := codec.Encode(mediaType, p, options...)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", mediaType)
+	return req, nil
+}
+
+// Post encodes the Pack in the given media type and posts it to the URL using http.DefaultClient
+func Post(url, mediaType string, p senml.Pack, options ...codec.Option) (*http.Response, error) {
+	req, err := NewRequest(http.MethodPost, url, mediaType, p, options...)
+	if err != nil {
+		return nil, err
+	}
+	return http.DefaultClient.Do(req)
+}
@@ -0,0 +1,154 @@
+package httpsenml
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/farshidtz/senml/v2"
+	"github.com/farshidtz/senml/v2/codec"
+)
+
+func referencePack() senml.Pack {
+	value := 22.1
+	return senml.Pack{
+		{BaseName: "dev123/", BaseTime: 1276020076, Name: "temp", Unit: senml.UnitCelsius, Value: &value},
+		{Name: "room", StringValue: "kitchen", Time: -1},
+	}
+}
+
+func TestHandler(t *testing.T) {
+	var received senml.Pack
+	handler := NewHandler(func(w http.ResponseWriter, r *http.Request, p senml.Pack) {
+		received = p
+		w.WriteHeader(http.StatusCreated)
+	}, SetMaxBodySize(200))
+
+	post := func(contentType, body string) *httptest.ResponseRecorder {
+		received = nil
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("supported media types", func(t *testing.T) {
+		for _, mediaType := range []string{senml.MediaTypeSenmlJSON, senml.MediaTypeSenmlCBOR, senml.MediaTypeSensmlXML} {
+			b, err := codec.Encode(mediaType, referencePack())
+			if err != nil {
+				t.Fatalf("Encoding error: %s", err)
+			}
+			rec := post(mediaType, string(b))
+			if rec.Code != http.StatusCreated {
+				t.Fatalf("Unexpected status for %s: %d: %s", mediaType, rec.Code, rec.Body)
+			}
+			if len(received) != 2 || received[0].BaseName != "dev123/" {
+				t.Fatalf("Unexpected pack for %s: %v", mediaType, received)
+			}
+		}
+	})
+
+	t.Run("unsupported media type", func(t *testing.T) {
+		for _, contentType := range []string{"", "text/html"} {
+			rec := post(contentType, `[{"n":"temp","v":1}]`)
+			if rec.Code != http.StatusUnsupportedMediaType {
+				t.Fatalf("Expected 415 for %q. Got: %d", contentType, rec.Code)
+			}
+		}
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		rec := post(senml.MediaTypeSenmlJSON, `[{"n":"temp","vs":"`+strings.Repeat("x", 200)+`"}]`)
+		if rec.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("Expected 413. Got: %d", rec.Code)
+		}
+	})
+
+	t.Run("too large without content length", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`[{"n":"temp","vs":"`+strings.Repeat("x", 200)+`"}]`))
+		req.ContentLength = -1
+		req.Header.Set("Content-Type", senml.MediaTypeSenmlJSON)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("Expected 413. Got: %d", rec.Code)
+		}
+	})
+
+	t.Run("malformed body", func(t *testing.T) {
+		rec := post(senml.MediaTypeSenmlJSON, `[{"n":"temp"`)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("Expected 400. Got: %d", rec.Code)
+		}
+	})
+
+	t.Run("invalid pack", func(t *testing.T) {
+		rec := post(senml.MediaTypeSenmlJSON, `[{"n":"temp#1","v":1}]`)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("Expected 400. Got: %d", rec.Code)
+		}
+		if received != nil {
+			t.Fatalf("Handler was called for invalid pack")
+		}
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	handler := Middleware(SetNormalize)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := PackFromContext(r.Context())
+		if !ok {
+			t.Fatalf("No pack in context")
+		}
+		fmt.Fprint(w, p[1].Name)
+	}))
+
+	b, _ := codec.EncodeJSON(referencePack())
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(b)))
+	req.Header.Set("Content-Type", senml.MediaTypeSenmlJSON+"; charset=utf-8")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != "dev123/room" {
+		t.Fatalf("Unexpected response: %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestPost(t *testing.T) {
+	server := httptest.NewServer(NewHandler(func(w http.ResponseWriter, r *http.Request, p senml.Pack) {
+		fmt.Fprintf(w, "%s %d", r.Header.Get("Content-Type"), len(p))
+	}))
+	defer server.Close()
+
+	res, err := Post(server.URL, senml.MediaTypeSenmlCBOR, referencePack())
+	if err != nil {
+		t.Fatalf("Error posting: %s", err)
+	}
+	defer res.Body.Close()
+	body, _ := ioutil.ReadAll(res.Body)
+	if res.StatusCode != http.StatusOK || string(body) != senml.MediaTypeSenmlCBOR+" 2" {
+		t.Fatalf("Unexpected response: %d: %s", res.StatusCode, body)
+	}
+
+	_, err = Post(server.URL, "text/html", referencePack())
+	if err == nil {
+		t.Fatalf("No error for unsupported media type")
+	}
+}
+
+// EXAMPLES
+
+func ExampleNewHandler() {
+	handler := NewHandler(func(w http.ResponseWriter, r *http.Request, p senml.Pack) {
+		for _, r := range p {
+			fmt.Println(r.Name, *r.Value)
+		}
+	}, SetNormalize)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`[{"bn":"room1/","n":"temp","v":23.5}]`))
+	req.Header.Set("Content-Type", senml.MediaTypeSenmlJSON)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	// Output: room1/temp 23.5
+}
@@ -0,0 +1,141 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// ErrNotAcceptable is returned when none of the registered media types is acceptable
+var ErrNotAcceptable = errors.New("no acceptable media type")
+
+// mediaRange is a media range of an HTTP Accept header: https://tools.ietf.org/html/rfc7231#section-5.3.2
+type mediaRange struct {
+	mainType string
+	subType  string
+	params   map[string]string
+	q        float64
+}
+
+// parseAccept parses the Accept header, skipping the malformed media ranges
+func parseAccept(accept string) []mediaRange {
+	if strings.TrimSpace(accept) == "" {
+		accept = "*/*"
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		slash := strings.IndexByte(mediaType, '/')
+		if slash < 0 {
+			continue
+		}
+		r := mediaRange{mainType: mediaType[:slash], subType: mediaType[slash+1:], params: params, q: 1}
+		if q, found := params["q"]; found {
+			r.q, err = strconv.ParseFloat(q, 64)
+			if err != nil || r.q < 0 || r.q > 1 {
+				continue
+			}
+			delete(r.params, "q")
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// specificity returns how specifically the media range matches the media type with parameters, or -1 if it does not match
+func (r mediaRange) specificity(mediaType string, params map[string]string) int {
+	slash := strings.IndexByte(mediaType, '/')
+	switch {
+	case slash < 0:
+		return -1
+	case r.mainType == "*" && r.subType == "*":
+		return 0
+	case r.mainType != mediaType[:slash]:
+		return -1
+	case r.subType == "*":
+		return 1
+	case r.subType != mediaType[slash+1:]:
+		return -1
+	}
+	for name, value := range r.params {
+		if params[name] != value {
+			return -1
+		}
+	}
+	return 2 + len(r.params)
+}
+
+// EncoderForAccept returns the registered media type which best matches the HTTP Accept header, along with its encoder.
+// Media types are ranked by their quality value, then by the specificity of the matching media range, and then by the order of registration.
+func (reg *Registry) EncoderForAccept(accept string) (string, Encoder, error) {
+	ranges := parseAccept(accept)
+
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	var (
+		bestType        string
+		bestQ           float64
+		bestSpecificity = -1
+	)
+	for _, candidate := range reg.order {
+		mediaType, params, err := mime.ParseMediaType(candidate)
+		if err != nil || !strings.Contains(mediaType, "/") || reg.codecs[candidate].Encoder == nil {
+			continue
+		}
+		// the quality of a media type is that of the most specific matching range
+		q, specificity := 0.0, -1
+		for _, r := range ranges {
+			if s := r.specificity(mediaType, params); s > specificity {
+				q, specificity = r.q, s
+			}
+		}
+		if q > bestQ || (q == bestQ && q > 0 && specificity > bestSpecificity) {
+			bestType, bestQ, bestSpecificity = candidate, q, specificity
+		}
+	}
+	if bestType == "" {
+		return "", nil, fmt.Errorf("%w: %s", ErrNotAcceptable, accept)
+	}
+	return bestType, reg.codecs[bestType].Encoder, nil
+}
+
+// DecoderForContentType returns the decoder registered for the media type of the HTTP Content-Type header.
+// Parameters such as the charset are ignored. Unregistered media types with a +json, +cbor or +xml structured syntax suffix
+// fall back to the decoders of SenML JSON, CBOR and XML respectively.
+func (reg *Registry) DecoderForContentType(contentType string) (Decoder, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedMediaType, contentType)
+	}
+	if c, found := reg.Lookup(mediaType); found && c.Decoder != nil {
+		return c.Decoder, nil
+	}
+
+	switch {
+	case strings.HasSuffix(mediaType, "+json"):
+		return DecodeJSON, nil
+	case strings.HasSuffix(mediaType, "+cbor"):
+		return DecodeCBOR, nil
+	case strings.HasSuffix(mediaType, "+xml"):
+		return DecodeXML, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedMediaType, contentType)
+}
+
+// EncoderForAccept returns the media type of the DefaultRegistry which best matches the HTTP Accept header, along with its encoder
+func EncoderForAccept(accept string) (string, Encoder, error) {
+	return DefaultRegistry.EncoderForAccept(accept)
+}
+
+// DecoderForContentType returns the decoder of the DefaultRegistry for the HTTP Content-Type header
+func DecoderForContentType(contentType string) (Decoder, error) {
+	return DefaultRegistry.DecoderForContentType(contentType)
+}
@@ -0,0 +1,142 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/farshidtz/senml/v2"
+)
+
+func TestEncoderForAccept(t *testing.T) {
+	tests := []struct {
+		accept    string
+		mediaType string
+	}{
+		{"", senml.MediaTypeSenmlJSON},
+		{"*/*", senml.MediaTypeSenmlJSON},
+		{"application/senml+cbor", senml.MediaTypeSenmlCBOR},
+		{"Application/SenML+CBOR", senml.MediaTypeSenmlCBOR},
+		{"application/senml+xml;q=0.5, application/sensml+json;q=0.8", senml.MediaTypeSensmlJSON},
+		{"text/*", senml.MediaTypeCustomSenmlCSV},
+		{"application/*;q=0.1, application/senml-exi", senml.MediaTypeSenmlEXI},
+		{"*/*;q=0.5, application/senml+json;q=0", senml.MediaTypeSensmlJSON},
+		{"application/senml+xml, application/senml+cbor", senml.MediaTypeSenmlCBOR},
+		{"text/html, application/senml+cbor;q=0.9, */*;q=0.1", senml.MediaTypeSenmlCBOR},
+		{"invalid, application/senml+cbor;q=2, application/senml+xml;q=0.3", senml.MediaTypeSenmlXML},
+		{"application/senml+json;charset=utf-8", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.accept, func(t *testing.T) {
+			mediaType, encoder, err := EncoderForAccept(test.accept)
+			if test.mediaType == "" {
+				if !errors.Is(err, ErrNotAcceptable) {
+					t.Fatalf("Expected not acceptable error. Got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error negotiating: %s", err)
+			}
+			if mediaType != test.mediaType || encoder == nil {
+				t.Fatalf("Expected %s. Got: %s", test.mediaType, mediaType)
+			}
+		})
+	}
+
+	t.Run("not acceptable", func(t *testing.T) {
+		_, _, err := EncoderForAccept("text/html, application/json")
+		if !errors.Is(err, ErrNotAcceptable) {
+			t.Fatalf("Expected not acceptable error. Got: %v", err)
+		}
+	})
+
+	t.Run("invalid media type", func(t *testing.T) {
+		reg := NewRegistry()
+		for _, mediaType := range []string{"senmljson", "", "application/json; q"} {
+			if err := reg.Register(mediaType, Codec{Encoder: EncodeJSON}); !errors.Is(err, ErrInvalidMediaType) {
+				t.Fatalf("Expected invalid media type error for %q. Got: %v", mediaType, err)
+			}
+		}
+		_, _, err := reg.EncoderForAccept("application/json")
+		if !errors.Is(err, ErrNotAcceptable) {
+			t.Fatalf("Expected not acceptable error. Got: %v", err)
+		}
+	})
+}
+
+func TestDecoderForContentType(t *testing.T) {
+	jsonBytes := []byte(jsonStringMinified)
+
+	for _, contentType := range []string{
+		senml.MediaTypeSenmlJSON,
+		"application/senml+json; charset=utf-8",
+		"APPLICATION/SENSML+JSON",
+		"application/vnd.example+json",
+	} {
+		decoder, err := DecoderForContentType(contentType)
+		if err != nil {
+			t.Fatalf("Error for %s: %s", contentType, err)
+		}
+		pack, err := decoder(jsonBytes)
+		if err != nil {
+			t.Fatalf("Error decoding %s: %s", contentType, err)
+		}
+		if err := compareFields(pack, referencePack()); err != nil {
+			t.Fatalf("Error matching %s records: %s", contentType, err)
+		}
+	}
+
+	t.Run("suffix fallbacks", func(t *testing.T) {
+		for contentType, data := range map[string][]byte{
+			"application/vnd.example+cbor": mustEncode(EncodeCBOR(referencePack())),
+			"application/vnd.example+xml":  mustEncode(EncodeXML(referencePack())),
+		} {
+			decoder, err := DecoderForContentType(contentType)
+			if err != nil {
+				t.Fatalf("Error for %s: %s", contentType, err)
+			}
+			pack, err := decoder(data)
+			if err != nil {
+				t.Fatalf("Error decoding %s: %s", contentType, err)
+			}
+			if err := compareFields(pack, referencePack()); err != nil {
+				t.Fatalf("Error matching %s records: %s", contentType, err)
+			}
+		}
+	})
+
+	t.Run("unsupported media type", func(t *testing.T) {
+		for _, contentType := range []string{"", "text/html", "application/json;;"} {
+			_, err := DecoderForContentType(contentType)
+			if !errors.Is(err, ErrUnsupportedMediaType) {
+				t.Fatalf("Expected unsupported media type error for %q. Got: %v", contentType, err)
+			}
+		}
+	})
+}
+
+func mustEncode(b []byte, err error) []byte {
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// EXAMPLES
+
+func ExampleEncoderForAccept() {
+	mediaType, encoder, err := EncoderForAccept("application/senml+xml;q=0.5, application/senml+cbor")
+	if err != nil {
+		panic(err) // handle the error, e.g. with 406 Not Acceptable
+	}
+
+	v := 23.1
+	dataOut, err := encoder(senml.Pack{{Name: "temp", Value: &v}})
+	if err != nil {
+		panic(err) // handle the error
+	}
+	fmt.Printf("%s %x", mediaType, dataOut)
+	// Output: application/senml+cbor 81a2006474656d7002fb403719999999999a
+}
@@ -3,6 +3,14 @@ package codec
 type codecOptions struct {
 	prettyPrint bool
 	header      bool
+	columns     []string
+	mapping     map[string]CSVField
+	autoDetect  bool
+	delimiter   rune
+	timeFormat  string
+	missing     string
+	annotate    bool
+	patch       bool
 }
 
 // SetPrettyPrint enables indentation for JSON and XML encoding
@@ -15,12 +23,69 @@ func SetDefaultHeader(o *codecOptions) {
 	o.header = true
 }
 
-// TODO set custom CSV header
-//func SetHeader(header string) Option {
-//	return func(o *codecOptions) {
-//		o.header = header
-//	}
-//}
+// SetBaseFields selects the columns of the BaseFieldsCSVHeader for CSV encoding/decoding,
+// so that packs are written and read with their base fields instead of in resolved form.
+// A pack read back is equal to the one written, except for the extensions.
+func SetBaseFields(o *codecOptions) {
+	o.columns = splitHeader(BaseFieldsCSVHeader)
+}
+
+// SetHeader enables a custom header for CSV encoding/decoding. The header is a comma-separated list of column names,
+// regardless of the delimiter, which selects and orders the columns, e.g. "Name,Time,Value".
+// Column names are matched to fields as described in CSVField.
+func SetHeader(header string) Option {
+	return func(o *codecOptions) {
+		o.header = true
+		o.columns = splitHeader(header)
+	}
+}
+
+// SetColumnMapping maps custom CSV column names to SenML fields, e.g. {"Sensor": CSVName, "Reading": CSVValue}.
+// The mapping takes precedence over the default column names.
+func SetColumnMapping(mapping map[string]CSVField) Option {
+	return func(o *codecOptions) {
+		o.mapping = mapping
+	}
+}
+
+// SetAutoDetectHeader enables CSV decoding with the columns detected from the header row.
+// Columns which do not match a SenML field are ignored.
+func SetAutoDetectHeader(o *codecOptions) {
+	o.autoDetect = true
+}
+
+// SetDelimiter sets the field delimiter for CSV encoding/decoding. The default is a comma.
+func SetDelimiter(delimiter rune) Option {
+	return func(o *codecOptions) {
+		o.delimiter = delimiter
+	}
+}
+
+// SetTimeFormat sets the layout of the time column for CSV encoding/decoding, e.g. time.RFC3339.
+// The default is seconds since the Unix epoch. Times are encoded in UTC.
+func SetTimeFormat(layout string) Option {
+	return func(o *codecOptions) {
+		o.timeFormat = layout
+	}
+}
+
+// SetMissingValue sets the text of missing values in wide CSV encoding/decoding, e.g. "NaN". The default is an empty field.
+func SetMissingValue(missing string) Option {
+	return func(o *codecOptions) {
+		o.missing = missing
+	}
+}
+
+// SetUnitAnnotation enables units in the header of wide CSV encoding, e.g. "room1/temp [Cel]"
+func SetUnitAnnotation(o *codecOptions) {
+	o.annotate = true
+}
+
+// SetPatch enables the encoding of SenML PATCH packs in the etch formats, with null values for deletion records.
+// Without it, the etch encoders encode SenML FETCH packs, whose records have no value.
+func SetPatch(o *codecOptions) {
+	o.patch = true
+}
 
 // Option is the function type for setting codec options
 type Option func(*codecOptions)
@@ -2,6 +2,7 @@ package codec
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/farshidtz/senml/v2"
 )
@@ -40,3 +41,20 @@ func ExampleSetDefaultHeader() {
 	// 946684700,0,lamp/brightness,lm,,100,,,
 	// 946684800,0,lamp/brightness,lm,,500,,,
 }
+
+func ExampleSetHeader() {
+	var p senml.Pack = []senml.Record{
+		{Time: 946684700, Name: "lamp/brightness", StringValue: "100", Unit: senml.UnitLumen},
+		{Time: 946684800, Name: "lamp/brightness", StringValue: "500", Unit: senml.UnitLumen},
+	}
+
+	dataOut, err := EncodeCSV(p, SetHeader("Name,Time,String Value"), SetTimeFormat(time.RFC3339))
+	if err != nil {
+		panic(err) // handle the error
+	}
+	fmt.Printf("%s", dataOut)
+	// Output:
+	// Name,Time,String Value
+	// lamp/brightness,1999-12-31T23:58:20Z,100
+	// lamp/brightness,2000-01-01T00:00:00Z,500
+}
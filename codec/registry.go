@@ -0,0 +1,220 @@
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/farshidtz/senml/v2"
+)
+
+// Codec is the set of functions for a media type.
+// Missing functions are derived from the others when registering, e.g. the Writer from the Encoder.
+type Codec struct {
+	Encoder Encoder
+	Decoder Decoder
+	Reader  Reader
+	Writer  Writer
+}
+
+// Registry holds codecs keyed by media type, as well as the mapping of CoAP Content-Format numbers to media types.
+// It is safe for concurrent use.
+type Registry struct {
+	mu             sync.RWMutex
+	codecs         map[string]Codec
+	order          []string // media types in order of registration, used as preference in negotiation
+	contentFormats map[uint16]string
+}
+
+// ErrInvalidMediaType is returned when registering a media type which is not of the form type/subtype
+var ErrInvalidMediaType = errors.New("invalid media type")
+
+// DefaultRegistry is the registry used by Encode and Decode, with the built-in codecs pre-registered
+var DefaultRegistry = newDefaultRegistry()
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		codecs:         make(map[string]Codec),
+		contentFormats: make(map[uint16]string),
+	}
+}
+
+func newDefaultRegistry() *Registry {
+	reg := NewRegistry()
+	// the built-in media types are valid, so registering them cannot fail
+	register := func(mediaType string, c Codec) {
+		if err := reg.Register(mediaType, c); err != nil {
+			panic(err)
+		}
+	}
+	for _, mediaType := range []string{senml.MediaTypeSenmlJSON, senml.MediaTypeSensmlJSON} {
+		register(mediaType, Codec{Encoder: EncodeJSON, Decoder: DecodeJSON})
+	}
+	for _, mediaType := range []string{senml.MediaTypeSenmlCBOR, senml.MediaTypeSensmlCBOR} {
+		register(mediaType, Codec{Encoder: EncodeCBOR, Decoder: DecodeCBOR})
+	}
+	for _, mediaType := range []string{senml.MediaTypeSenmlXML, senml.MediaTypeSensmlXML} {
+		register(mediaType, Codec{Encoder: EncodeXML, Decoder: DecodeXML})
+	}
+	for _, mediaType := range []string{senml.MediaTypeSenmlEXI, senml.MediaTypeSensmlEXI} {
+		register(mediaType, Codec{Encoder: EncodeEXI, Decoder: DecodeEXI})
+	}
+	register(senml.MediaTypeSenmlEtchJSON, Codec{Encoder: EncodeEtchJSON, Decoder: DecodeJSON})
+	register(senml.MediaTypeSenmlEtchCBOR, Codec{Encoder: EncodeEtchCBOR, Decoder: DecodeCBOR})
+	register(senml.MediaTypeCustomSenmlProtobuf, Codec{Encoder: EncodeProtobuf, Decoder: DecodeProtobuf})
+	// SenSML streams are sequences of length-delimited frames
+	register(senml.MediaTypeCustomSensmlProtobuf, Codec{Reader: ReadProtobuf, Writer: WriteProtobuf})
+	for _, mediaType := range []string{senml.MediaTypeCustomSenmlCSV, senml.MediaTypeCustomSensmlCSV} {
+		register(mediaType, Codec{Encoder: EncodeCSV, Decoder: DecodeCSV, Reader: ReadCSV, Writer: WriteCSV})
+	}
+
+	reg.RegisterContentFormat(senml.ContentFormatSenmlJSON, senml.MediaTypeSenmlJSON)
+	reg.RegisterContentFormat(senml.ContentFormatSensmlJSON, senml.MediaTypeSensmlJSON)
+	reg.RegisterContentFormat(senml.ContentFormatSenmlCBOR, senml.MediaTypeSenmlCBOR)
+	reg.RegisterContentFormat(senml.ContentFormatSensmlCBOR, senml.MediaTypeSensmlCBOR)
+	reg.RegisterContentFormat(senml.ContentFormatSenmlEXI, senml.MediaTypeSenmlEXI)
+	reg.RegisterContentFormat(senml.ContentFormatSensmlEXI, senml.MediaTypeSensmlEXI)
+	reg.RegisterContentFormat(senml.ContentFormatSenmlXML, senml.MediaTypeSenmlXML)
+	reg.RegisterContentFormat(senml.ContentFormatSensmlXML, senml.MediaTypeSensmlXML)
+	reg.RegisterContentFormat(senml.ContentFormatSenmlEtchJSON, senml.MediaTypeSenmlEtchJSON)
+	reg.RegisterContentFormat(senml.ContentFormatSenmlEtchCBOR, senml.MediaTypeSenmlEtchCBOR)
+	return reg
+}
+
+// normalizeMediaType returns the media type in lower case without surrounding spaces
+func normalizeMediaType(mediaType string) string {
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
+
+// Register adds the codec for the media type, replacing any existing one.
+// It returns an error wrapping ErrInvalidMediaType if the media type is not of the form type/subtype.
+func (reg *Registry) Register(mediaType string, c Codec) error {
+	if parsed, _, err := mime.ParseMediaType(mediaType); err != nil || strings.IndexByte(parsed, '/') < 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidMediaType, mediaType)
+	}
+	if c.Writer == nil && c.Encoder != nil {
+		encode := c.Encoder
+		c.Writer = func(p senml.Pack, w io.Writer, options ...Option) error {
+			b, err := encode(p, options...)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(b)
+			return err
+		}
+	}
+	if c.Encoder == nil && c.Writer != nil {
+		write := c.Writer
+		c.Encoder = func(p senml.Pack, options ...Option) ([]byte, error) {
+			var buf bytes.Buffer
+			err := write(p, &buf, options...)
+			if err != nil {
+				return nil, err
+			}
+			return buf.Bytes(), nil
+		}
+	}
+	if c.Reader == nil && c.Decoder != nil {
+		decode := c.Decoder
+		c.Reader = func(r io.Reader, options ...Option) (senml.Pack, error) {
+			b, err := ioutil.ReadAll(r)
+			if err != nil {
+				return nil, err
+			}
+			return decode(b, options...)
+		}
+	}
+	if c.Decoder == nil && c.Reader != nil {
+		read := c.Reader
+		c.Decoder = func(b []byte, options ...Option) (senml.Pack, error) {
+			return read(bytes.NewReader(b), options...)
+		}
+	}
+
+	mediaType = normalizeMediaType(mediaType)
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if _, found := reg.codecs[mediaType]; !found {
+		reg.order = append(reg.order, mediaType)
+	}
+	reg.codecs[mediaType] = c
+	return nil
+}
+
+// RegisterContentFormat maps the CoAP Content-Format number to the media type
+func (reg *Registry) RegisterContentFormat(contentFormat uint16, mediaType string) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.contentFormats[contentFormat] = normalizeMediaType(mediaType)
+}
+
+// Lookup returns the codec registered for the media type
+func (reg *Registry) Lookup(mediaType string) (Codec, bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	c, found := reg.codecs[normalizeMediaType(mediaType)]
+	return c, found
+}
+
+// MediaType returns the media type registered for the CoAP Content-Format number
+func (reg *Registry) MediaType(contentFormat uint16) (string, bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	mediaType, found := reg.contentFormats[contentFormat]
+	return mediaType, found
+}
+
+// MediaTypes returns the registered media types in lexical order
+func (reg *Registry) MediaTypes() []string {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	mediaTypes := make([]string, 0, len(reg.codecs))
+	for mediaType := range reg.codecs {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	sort.Strings(mediaTypes)
+	return mediaTypes
+}
+
+// Encode encodes the pack using the codec registered for the media type
+func (reg *Registry) Encode(mediaType string, p senml.Pack, options ...Option) ([]byte, error) {
+	c, found := reg.Lookup(mediaType)
+	if !found || c.Encoder == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedMediaType, mediaType)
+	}
+	return c.Encoder(p, options...)
+}
+
+// Decode decodes the bytes using the codec registered for the media type
+func (reg *Registry) Decode(mediaType string, b []byte, options ...Option) (senml.Pack, error) {
+	c, found := reg.Lookup(mediaType)
+	if !found || c.Decoder == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedMediaType, mediaType)
+	}
+	return c.Decoder(b, options...)
+}
+
+// Read reads a pack from the reader using the codec registered for the media type
+func (reg *Registry) Read(mediaType string, r io.Reader, options ...Option) (senml.Pack, error) {
+	c, found := reg.Lookup(mediaType)
+	if !found || c.Reader == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedMediaType, mediaType)
+	}
+	return c.Reader(r, options...)
+}
+
+// Write writes the pack on the writer using the codec registered for the media type
+func (reg *Registry) Write(mediaType string, p senml.Pack, w io.Writer, options ...Option) error {
+	c, found := reg.Lookup(mediaType)
+	if !found || c.Writer == nil {
+		return fmt.Errorf("%w: %s", ErrUnsupportedMediaType, mediaType)
+	}
+	return c.Writer(p, w, options...)
+}
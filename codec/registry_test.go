@@ -0,0 +1,147 @@
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/farshidtz/senml/v2"
+)
+
+func TestRegistry(t *testing.T) {
+
+	t.Run("built-in codecs", func(t *testing.T) {
+		for _, mediaType := range []string{
+			senml.MediaTypeSenmlJSON, senml.MediaTypeSensmlJSON,
+			senml.MediaTypeSenmlCBOR, senml.MediaTypeSensmlCBOR,
+			senml.MediaTypeSenmlXML, senml.MediaTypeSensmlXML,
+			senml.MediaTypeSenmlEXI, senml.MediaTypeSensmlEXI,
+		} {
+			dataOut, err := Encode(mediaType, referencePack())
+			if err != nil {
+				t.Fatalf("Error encoding %s: %s", mediaType, err)
+			}
+			pack, err := Decode(mediaType, dataOut)
+			if err != nil {
+				t.Fatalf("Error decoding %s: %s", mediaType, err)
+			}
+			if err := compareFields(pack, referencePack()); err != nil {
+				t.Fatalf("Error matching %s records: %s", mediaType, err)
+			}
+		}
+	})
+
+	t.Run("content formats", func(t *testing.T) {
+		for contentFormat, expected := range map[uint16]string{
+			senml.ContentFormatSenmlJSON:  senml.MediaTypeSenmlJSON,
+			senml.ContentFormatSensmlCBOR: senml.MediaTypeSensmlCBOR,
+			senml.ContentFormatSenmlXML:   senml.MediaTypeSenmlXML,
+		} {
+			mediaType, found := DefaultRegistry.MediaType(contentFormat)
+			if !found || mediaType != expected {
+				t.Fatalf("Expected %s for %d. Got: %s", expected, contentFormat, mediaType)
+			}
+		}
+		if _, found := DefaultRegistry.MediaType(0); found {
+			t.Fatalf("Found media type for unregistered content format")
+		}
+	})
+
+	t.Run("custom codec", func(t *testing.T) {
+		reg := NewRegistry()
+		reg.Register("Text/Plain", Codec{
+			Writer: func(p senml.Pack, w io.Writer, _ ...Option) error {
+				for _, r := range p {
+					fmt.Fprintln(w, r.Name)
+				}
+				return nil
+			},
+			Reader: func(r io.Reader, _ ...Option) (senml.Pack, error) {
+				b, err := ioutil.ReadAll(r)
+				if err != nil {
+					return nil, err
+				}
+				var p senml.Pack
+				for _, name := range strings.Fields(string(b)) {
+					p = append(p, senml.Record{Name: name})
+				}
+				return p, nil
+			},
+		})
+		reg.RegisterContentFormat(0, "text/plain")
+
+		// encoder and decoder are derived from the writer and reader
+		dataOut, err := reg.Encode("text/plain", referencePack())
+		if err != nil {
+			t.Fatalf("Encoding error: %s", err)
+		}
+		if string(dataOut) != "temp\nroom\ndata\nok\n" {
+			t.Fatalf("Unexpected encoding: %q", dataOut)
+		}
+		pack, err := reg.Decode("text/plain", dataOut)
+		if err != nil {
+			t.Fatalf("Error decoding: %s", err)
+		}
+		if len(pack) != 4 || pack[3].Name != "ok" {
+			t.Fatalf("Unexpected pack: %v", pack)
+		}
+
+		mediaType, _ := reg.MediaType(0)
+		if mediaType != "text/plain" {
+			t.Fatalf("Unexpected media type for content format: %s", mediaType)
+		}
+		if mediaTypes := reg.MediaTypes(); len(mediaTypes) != 1 || mediaTypes[0] != "text/plain" {
+			t.Fatalf("Unexpected media types: %v", mediaTypes)
+		}
+	})
+
+	t.Run("derived reader and writer", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := DefaultRegistry.Write(senml.MediaTypeSenmlJSON, referencePack(), &buf)
+		if err != nil {
+			t.Fatalf("Error writing: %s", err)
+		}
+		if buf.String() != jsonStringMinified {
+			t.Fatalf("Unexpected output: %s", buf.String())
+		}
+		pack, err := DefaultRegistry.Read(senml.MediaTypeSenmlJSON, &buf)
+		if err != nil {
+			t.Fatalf("Error reading: %s", err)
+		}
+		if err := compareFields(pack, referencePack()); err != nil {
+			t.Fatalf("Error matching records: %s", err)
+		}
+	})
+
+	t.Run("unsupported media type", func(t *testing.T) {
+		_, err := Encode("application/unknown", referencePack())
+		if !errors.Is(err, ErrUnsupportedMediaType) {
+			t.Fatalf("Expected unsupported media type error. Got: %v", err)
+		}
+		_, err = NewRegistry().Decode(senml.MediaTypeSenmlJSON, []byte(jsonStringMinified))
+		if !errors.Is(err, ErrUnsupportedMediaType) {
+			t.Fatalf("Expected unsupported media type error. Got: %v", err)
+		}
+	})
+}
+
+// EXAMPLES
+
+func ExampleRegistry_Register() {
+	reg := NewRegistry()
+	reg.Register("application/vnd.example+json", Codec{Encoder: EncodeJSON, Decoder: DecodeJSON})
+	reg.RegisterContentFormat(65000, "application/vnd.example+json")
+
+	mediaType, _ := reg.MediaType(65000)
+	v := 23.1
+	dataOut, err := reg.Encode(mediaType, senml.Pack{{Name: "temp", Value: &v}})
+	if err != nil {
+		panic(err) // handle the error
+	}
+	fmt.Printf("%s", dataOut)
+	// Output: [{"n":"temp","v":23.1}]
+}
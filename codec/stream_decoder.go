@@ -0,0 +1,323 @@
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/farshidtz/senml/v2"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// StreamDecoder reads a SenML Pack from an input stream one record at a time.
+// It is suitable for SenSML streams which may never end.
+type StreamDecoder struct {
+	next    func() (senml.Record, error)
+	base    senml.Record
+	options []senml.NormalizeOption
+	err     error
+}
+
+// NewStreamDecoder returns a decoder that reads records of the given media type from r.
+// The supported media types are the SenML and SenSML variants of JSON, CBOR and XML.
+// The options are those of senml.Pack.Normalize, e.g. senml.SetClock or senml.SetKeepRelativeTime.
+// Relative times of all records are resolved using the current time when the decoder is created, unless kept relative.
+func NewStreamDecoder(mediaType string, r io.Reader, options ...senml.NormalizeOption) (*StreamDecoder, error) {
+	d := &StreamDecoder{options: senml.SameTime(options...)}
+	switch mediaType {
+	case senml.MediaTypeSenmlJSON, senml.MediaTypeSensmlJSON:
+		d.next = jsonRecords(r)
+	case senml.MediaTypeSenmlCBOR, senml.MediaTypeSensmlCBOR:
+		d.next = cborRecords(r)
+	case senml.MediaTypeSenmlXML, senml.MediaTypeSensmlXML:
+		d.next = xmlRecords(r)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedMediaType, mediaType)
+	}
+	return d, nil
+}
+
+// Next reads the next record from the stream and returns it in resolved form.
+// The base fields of preceding records are tracked and applied to the returned record, as done by senml.Pack.Normalize.
+// Next returns io.EOF when the end of the pack is reached.
+func (d *StreamDecoder) Next() (senml.Record, error) {
+	if d.err != nil {
+		return senml.Record{}, d.err
+	}
+	r, err := d.next()
+	if err != nil {
+		d.err = err
+		return senml.Record{}, err
+	}
+	return d.resolve(r), nil
+}
+
+// resolve updates the base fields from the given record and returns the record in resolved form
+func (d *StreamDecoder) resolve(r senml.Record) senml.Record {
+	if r.BaseName != "" {
+		d.base.BaseName = r.BaseName
+	}
+	if r.BaseTime != 0 {
+		d.base.BaseTime = r.BaseTime
+	}
+	if r.BaseUnit != "" {
+		d.base.BaseUnit = r.BaseUnit
+	}
+	if r.BaseVersion != nil {
+		d.base.BaseVersion = r.BaseVersion
+	}
+	if r.BaseValue != nil {
+		d.base.BaseValue = r.BaseValue
+	}
+	if r.BaseSum != nil {
+		d.base.BaseSum = r.BaseSum
+	}
+
+	r.BaseName = d.base.BaseName
+	r.BaseTime = d.base.BaseTime
+	r.BaseUnit = d.base.BaseUnit
+	r.BaseVersion = d.base.BaseVersion
+	r.BaseValue = d.base.BaseValue
+	r.BaseSum = d.base.BaseSum
+
+	p := senml.Pack{r}
+	p.Normalize(d.options...)
+	return p[0]
+}
+
+func jsonRecords(r io.Reader) func() (senml.Record, error) {
+	dec := json.NewDecoder(r)
+	started := false
+	return func() (senml.Record, error) {
+		if !started {
+			token, err := dec.Token()
+			if err != nil {
+				return senml.Record{}, unexpectedEOF(err)
+			}
+			if delim, ok := token.(json.Delim); !ok || delim != '[' {
+				return senml.Record{}, fmt.Errorf("unexpected token at start of pack: %v", token)
+			}
+			started = true
+		}
+
+		if !dec.More() {
+			token, err := dec.Token()
+			if err != nil {
+				return senml.Record{}, unexpectedEOF(err)
+			}
+			if delim, ok := token.(json.Delim); !ok || delim != ']' {
+				return senml.Record{}, fmt.Errorf("unexpected token at end of pack: %v", token)
+			}
+			return senml.Record{}, io.EOF
+		}
+
+		var record senml.Record
+		err := dec.Decode(&record)
+		if err != nil {
+			return senml.Record{}, unexpectedEOF(err)
+		}
+		return record, nil
+	}
+}
+
+func cborRecords(r io.Reader) func() (senml.Record, error) {
+	br := bufio.NewReader(r)
+	started := false
+	indefinite := false
+	var remaining uint64
+	return func() (senml.Record, error) {
+		if !started {
+			major, ai, val, err := readCBORHead(br, nil)
+			if err != nil {
+				return senml.Record{}, unexpectedEOF(err)
+			}
+			if major != cborMajorArray {
+				return senml.Record{}, fmt.Errorf("unexpected CBOR major type at start of pack: %d", major)
+			}
+			indefinite = ai == cborIndefinite
+			remaining = val
+			started = true
+		}
+
+		if indefinite {
+			b, err := br.Peek(1)
+			if err != nil {
+				return senml.Record{}, unexpectedEOF(err)
+			}
+			if b[0] == cborBreak {
+				br.ReadByte()
+				return senml.Record{}, io.EOF
+			}
+		} else {
+			if remaining == 0 {
+				return senml.Record{}, io.EOF
+			}
+			remaining--
+		}
+
+		var item bytes.Buffer
+		err := readCBORItem(br, &item, 0)
+		if err != nil {
+			return senml.Record{}, unexpectedEOF(err)
+		}
+		var record senml.Record
+		err = cbor.Unmarshal(item.Bytes(), &record)
+		if err != nil {
+			return senml.Record{}, err
+		}
+		return record, nil
+	}
+}
+
+func xmlRecords(r io.Reader) func() (senml.Record, error) {
+	dec := xml.NewDecoder(r)
+	started := false
+	return func() (senml.Record, error) {
+		for {
+			token, err := dec.Token()
+			if err != nil {
+				return senml.Record{}, unexpectedEOF(err)
+			}
+			switch t := token.(type) {
+			case xml.StartElement:
+				if !started {
+					if t.Name.Local != "sensml" {
+						return senml.Record{}, fmt.Errorf("unexpected element at start of pack: %s", t.Name.Local)
+					}
+					started = true
+					continue
+				}
+				if t.Name.Local != "senml" {
+					return senml.Record{}, fmt.Errorf("unexpected element in pack: %s", t.Name.Local)
+				}
+				var record senml.Record
+				err = dec.DecodeElement(&record, &t)
+				if err != nil {
+					return senml.Record{}, unexpectedEOF(err)
+				}
+				return record, nil
+			case xml.EndElement:
+				// the decoder checks that end elements match the start elements
+				return senml.Record{}, io.EOF
+			}
+		}
+	}
+}
+
+// unexpectedEOF converts io.EOF to io.ErrUnexpectedEOF for streams ending before the end of pack
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+const (
+	cborMajorBytes  = 2
+	cborMajorText   = 3
+	cborMajorArray  = 4
+	cborMajorMap    = 5
+	cborMajorTag    = 6
+	cborMajorSimple = 7
+	cborIndefinite  = 31
+	cborBreak       = 0xff
+	cborMaxDepth    = 32
+)
+
+// readCBORHead reads the head of a CBOR data item and returns its major type, additional information and argument.
+// The bytes of the head are copied to w, if not nil.
+func readCBORHead(r *bufio.Reader, w *bytes.Buffer) (major, ai byte, val uint64, err error) {
+	head, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	major, ai = head>>5, head&0x1f
+	if w != nil {
+		w.WriteByte(head)
+	}
+
+	switch {
+	case ai < 24:
+		val = uint64(ai)
+	case ai <= 27:
+		for n := 0; n < 1<<(ai-24); n++ {
+			b, err := r.ReadByte()
+			if err != nil {
+				return 0, 0, 0, unexpectedEOF(err)
+			}
+			if w != nil {
+				w.WriteByte(b)
+			}
+			val = val<<8 | uint64(b)
+		}
+	case ai == cborIndefinite:
+		switch major {
+		case cborMajorBytes, cborMajorText, cborMajorArray, cborMajorMap:
+		case cborMajorSimple:
+			return 0, 0, 0, fmt.Errorf("unexpected CBOR break")
+		default:
+			return 0, 0, 0, fmt.Errorf("unexpected indefinite length for CBOR major type: %d", major)
+		}
+	default:
+		return 0, 0, 0, fmt.Errorf("invalid CBOR additional information: %d", ai)
+	}
+	return major, ai, val, nil
+}
+
+// readCBORItem copies one complete CBOR data item from r to w
+func readCBORItem(r *bufio.Reader, w *bytes.Buffer, depth int) error {
+	if depth > cborMaxDepth {
+		return fmt.Errorf("exceeded max CBOR nesting depth: %d", cborMaxDepth)
+	}
+	major, ai, val, err := readCBORHead(r, w)
+	if err != nil {
+		return err
+	}
+
+	if ai == cborIndefinite {
+		for {
+			b, err := r.Peek(1)
+			if err != nil {
+				return unexpectedEOF(err)
+			}
+			if b[0] == cborBreak {
+				r.ReadByte()
+				w.WriteByte(cborBreak)
+				return nil
+			}
+			items := 1
+			if major == cborMajorMap {
+				items = 2
+			}
+			for i := 0; i < items; i++ {
+				err = readCBORItem(r, w, depth+1)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	switch major {
+	case cborMajorBytes, cborMajorText:
+		_, err = io.CopyN(w, r, int64(val))
+		return unexpectedEOF(err)
+	case cborMajorArray, cborMajorMap:
+		items := val
+		if major == cborMajorMap {
+			items *= 2
+		}
+		for i := uint64(0); i < items; i++ {
+			err = readCBORItem(r, w, depth+1)
+			if err != nil {
+				return unexpectedEOF(err)
+			}
+		}
+	case cborMajorTag:
+		return unexpectedEOF(readCBORItem(r, w, depth+1))
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/farshidtz/senml/v2"
+	"github.com/fxamacker/cbor/v2"
+)
+
+func decodeStream(mediaType string, data []byte) (senml.Pack, error) {
+	d, err := NewStreamDecoder(mediaType, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	var p senml.Pack
+	for {
+		r, err := d.Next()
+		if err == io.EOF {
+			return p, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		p = append(p, r)
+	}
+}
+
+func TestStreamDecoder(t *testing.T) {
+	ref := referencePack(true)
+	ref.Normalize()
+
+	encoders := map[string]Encoder{
+		senml.MediaTypeSensmlJSON: EncodeJSON,
+		senml.MediaTypeSensmlCBOR: EncodeCBOR,
+		senml.MediaTypeSensmlXML:  EncodeXML,
+	}
+	for mediaType, encode := range encoders {
+		t.Run(mediaType, func(t *testing.T) {
+			data, err := encode(referencePack(true))
+			if err != nil {
+				t.Fatalf("Encoding error: %s", err)
+			}
+
+			pack, err := decodeStream(mediaType, data)
+			if err != nil {
+				t.Fatalf("Error decoding: %s", err)
+			}
+
+			if err := compareFields(pack, ref); err != nil {
+				t.Fatalf("Error matching records: %s", err)
+			}
+		})
+	}
+
+	t.Run("CBOR indefinite-length array", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc := cbor.NewEncoder(&buf)
+		enc.StartIndefiniteArray()
+		for _, r := range referencePack(true) {
+			enc.Encode(r)
+		}
+		enc.EndIndefinite()
+
+		pack, err := decodeStream(senml.MediaTypeSensmlCBOR, buf.Bytes())
+		if err != nil {
+			t.Fatalf("Error decoding: %s", err)
+		}
+
+		if err := compareFields(pack, ref); err != nil {
+			t.Fatalf("Error matching records: %s", err)
+		}
+	})
+
+	t.Run("CBOR reference", func(t *testing.T) {
+		cborBytes, err := hex.DecodeString(cborHexBytesString)
+		if err != nil {
+			t.Fatalf("Error decoding test value: %s", err)
+		}
+
+		pack, err := decodeStream(senml.MediaTypeSenmlCBOR, cborBytes)
+		if err != nil {
+			t.Fatalf("Error decoding: %s", err)
+		}
+		if len(pack) != 4 || pack[3].Name != "dev123ok" || pack[3].Unit != "degC" {
+			t.Fatalf("Unexpected records: %v", pack)
+		}
+	})
+
+	t.Run("empty pack", func(t *testing.T) {
+		for mediaType, data := range map[string]string{
+			senml.MediaTypeSensmlJSON: `[]`,
+			senml.MediaTypeSensmlCBOR: "\x9f\xff",
+			senml.MediaTypeSensmlXML:  `<sensml xmlns="urn:ietf:params:xml:ns:senml"></sensml>`,
+		} {
+			pack, err := decodeStream(mediaType, []byte(data))
+			if err != nil {
+				t.Fatalf("Error for valid, empty %s pack: %s", mediaType, err)
+			}
+			if len(pack) != 0 {
+				t.Fatalf("Unexpected records in empty %s pack: %v", mediaType, pack)
+			}
+		}
+	})
+
+	t.Run("truncated stream", func(t *testing.T) {
+		for mediaType, data := range map[string]string{
+			senml.MediaTypeSensmlJSON: `[{"n":"a","v":1},{"n":"b","v":2}`,
+			senml.MediaTypeSensmlCBOR: "\x9f\xa2\x00\x61a\x02\x01",
+			senml.MediaTypeSensmlXML:  `<sensml xmlns="urn:ietf:params:xml:ns:senml"><senml n="a" v="1"></senml>`,
+		} {
+			d, err := NewStreamDecoder(mediaType, strings.NewReader(data))
+			if err != nil {
+				t.Fatalf("Error creating decoder: %s", err)
+			}
+			r, err := d.Next()
+			if err != nil {
+				t.Fatalf("Error reading first %s record: %s", mediaType, err)
+			}
+			if r.Name != "a" || *r.Value != 1 {
+				t.Fatalf("Unexpected first %s record: %v", mediaType, r)
+			}
+			for err == nil {
+				_, err = d.Next()
+			}
+			if err == io.EOF {
+				t.Fatalf("No error for truncated %s stream", mediaType)
+			}
+		}
+	})
+
+	t.Run("invalid pack", func(t *testing.T) {
+		for mediaType, data := range map[string]string{
+			senml.MediaTypeSensmlJSON: `{"n":"a","v":1}`,
+			senml.MediaTypeSensmlCBOR: "\xa2\x00\x61a\x02\x01",
+			senml.MediaTypeSensmlXML:  `<senml n="a" v="1"></senml>`,
+		} {
+			_, err := decodeStream(mediaType, []byte(data))
+			if err == nil {
+				t.Fatalf("No error for %s record out of pack", mediaType)
+			}
+		}
+	})
+
+	t.Run("relative times", func(t *testing.T) {
+		data := `[{"n":"a","t":-10,"v":1},{"n":"b","t":-5,"v":2}]`
+		calls := 0
+		clock := func() time.Time {
+			calls++
+			return time.Unix(int64(1320078430+calls), 0)
+		}
+		d, err := NewStreamDecoder(senml.MediaTypeSensmlJSON, strings.NewReader(data), senml.SetClock(clock))
+		if err != nil {
+			t.Fatalf("Error creating decoder: %s", err)
+		}
+		for _, expected := range []float64{1320078421, 1320078426} {
+			r, err := d.Next()
+			if err != nil || r.Time != expected {
+				t.Fatalf("Expected time %v. Got %v: %v", expected, r.Time, err)
+			}
+		}
+
+		d, err = NewStreamDecoder(senml.MediaTypeSensmlJSON, strings.NewReader(data), senml.SetKeepRelativeTime)
+		if err != nil {
+			t.Fatalf("Error creating decoder: %s", err)
+		}
+		if r, err := d.Next(); err != nil || r.Time != -10 {
+			t.Fatalf("Expected relative time -10. Got %v: %v", r.Time, err)
+		}
+	})
+
+	t.Run("unsupported media type", func(t *testing.T) {
+		_, err := NewStreamDecoder(senml.MediaTypeCustomSensmlCSV, strings.NewReader(""))
+		if err == nil {
+			t.Fatalf("No error for unsupported media type")
+		}
+	})
+}
+
+// EXAMPLES
+
+func ExampleStreamDecoder() {
+	input := `[{"bn":"room1/temp","bu":"Cel","bt":1276020076,"v":23.5},{"t":15,"v":23.6}]`
+
+	decoder, err := NewStreamDecoder(senml.MediaTypeSensmlJSON, strings.NewReader(input))
+	if err != nil {
+		panic(err) // handle the error
+	}
+	for {
+		record, err := decoder.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			panic(err) // handle the error
+		}
+		fmt.Println(record.Name, record.Time, *record.Value, record.Unit)
+	}
+	// Output:
+	// room1/temp 1.276020076e+09 23.5 Cel
+	// room1/temp 1.276020091e+09 23.6 Cel
+}
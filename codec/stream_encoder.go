@@ -0,0 +1,158 @@
+package codec
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/farshidtz/senml/v2"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// StreamEncoder writes a SenML Pack to an output stream one record at a time.
+// It is suitable for SenSML streams where records are produced over time.
+type StreamEncoder struct {
+	w      io.Writer
+	format string
+	o      *codecOptions
+	cbor   *cbor.Encoder
+	count  int
+	closed bool
+}
+
+// NewStreamEncoder starts a pack of the given media type on w and returns an encoder for writing its records.
+// The supported media types are the SenML and SenSML variants of JSON, CBOR and XML.
+// CBOR packs are written as indefinite-length arrays.
+// The pretty print option is supported for JSON and XML.
+func NewStreamEncoder(mediaType string, w io.Writer, options ...Option) (*StreamEncoder, error) {
+	o := &codecOptions{
+		prettyPrint: false,
+	}
+	for _, opt := range options {
+		opt(o)
+	}
+
+	e := &StreamEncoder{w: w, o: o}
+	var err error
+	switch mediaType {
+	case senml.MediaTypeSenmlJSON, senml.MediaTypeSensmlJSON:
+		e.format = "json"
+		_, err = io.WriteString(w, "[")
+	case senml.MediaTypeSenmlCBOR, senml.MediaTypeSensmlCBOR:
+		e.format = "cbor"
+		e.cbor = cbor.NewEncoder(w)
+		err = e.cbor.StartIndefiniteArray()
+	case senml.MediaTypeSenmlXML, senml.MediaTypeSensmlXML:
+		e.format = "xml"
+		_, err = io.WriteString(w, `<sensml xmlns="urn:ietf:params:xml:ns:senml">`)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedMediaType, mediaType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e, e.flush()
+}
+
+// Encode writes the record to the stream
+func (e *StreamEncoder) Encode(r senml.Record) error {
+	if e.closed {
+		return fmt.Errorf("encoder is closed")
+	}
+
+	var err error
+	switch e.format {
+	case "json":
+		err = e.encodeJSON(r)
+	case "cbor":
+		err = e.cbor.Encode(r)
+	case "xml":
+		err = e.encodeXML(r)
+	}
+	if err != nil {
+		return err
+	}
+	e.count++
+	return e.flush()
+}
+
+// Close ends the pack. It does not close the underlying writer.
+func (e *StreamEncoder) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+
+	var err error
+	switch e.format {
+	case "json":
+		if e.o.prettyPrint {
+			_, err = io.WriteString(e.w, "\n]\n")
+		} else {
+			_, err = io.WriteString(e.w, "]")
+		}
+	case "cbor":
+		err = e.cbor.EndIndefinite()
+	case "xml":
+		if e.o.prettyPrint {
+			_, err = io.WriteString(e.w, "\n</sensml>")
+		} else {
+			_, err = io.WriteString(e.w, "</sensml>")
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return e.flush()
+}
+
+func (e *StreamEncoder) encodeJSON(r senml.Record) error {
+	recData, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	var separator string
+	switch {
+	case e.o.prettyPrint && e.count == 0:
+		separator = "\n  "
+	case e.o.prettyPrint:
+		separator = ",\n  "
+	case e.count != 0:
+		separator = ","
+	}
+	_, err = io.WriteString(e.w, separator)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(recData)
+	return err
+}
+
+func (e *StreamEncoder) encodeXML(r senml.Record) error {
+	recData, err := xml.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	if e.o.prettyPrint {
+		_, err = io.WriteString(e.w, "\n  ")
+		if err != nil {
+			return err
+		}
+	}
+	_, err = e.w.Write(recData)
+	return err
+}
+
+// flush flushes the underlying writer, if it is buffered
+func (e *StreamEncoder) flush() error {
+	switch w := e.w.(type) {
+	case interface{ Flush() error }:
+		return w.Flush()
+	case interface{ Flush() }: // e.g. http.Flusher
+		w.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/farshidtz/senml/v2"
+)
+
+func encodeStream(mediaType string, p senml.Pack, options ...Option) ([]byte, error) {
+	var buf bytes.Buffer
+	e, err := NewStreamEncoder(mediaType, &buf, options...)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range p {
+		err = e.Encode(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = e.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func TestStreamEncoder(t *testing.T) {
+
+	t.Run("JSON", func(t *testing.T) {
+		dataOut, err := encodeStream(senml.MediaTypeSensmlJSON, referencePack())
+		if err != nil {
+			t.Fatalf("Encoding error: %s", err)
+		}
+		if string(dataOut) != jsonStringMinified {
+			t.Logf("Expected:\n'%s'", jsonStringMinified)
+			t.Fatalf("Got:\n'%s'", dataOut)
+		}
+	})
+
+	t.Run("JSON pretty", func(t *testing.T) {
+		dataOut, err := encodeStream(senml.MediaTypeSensmlJSON, referencePack(), SetPrettyPrint)
+		if err != nil {
+			t.Fatalf("Encoding error: %s", err)
+		}
+		if string(dataOut) != jsonStringPretty {
+			t.Logf("Expected:\n'%s'", jsonStringPretty)
+			t.Fatalf("Got:\n'%s'", dataOut)
+		}
+	})
+
+	t.Run("XML", func(t *testing.T) {
+		dataOut, err := encodeStream(senml.MediaTypeSensmlXML, referencePack())
+		if err != nil {
+			t.Fatalf("Encoding error: %s", err)
+		}
+		if string(dataOut) != xmlStringMinified {
+			t.Logf("Expected:\n'%s'", xmlStringMinified)
+			t.Fatalf("Got:\n'%s'", dataOut)
+		}
+	})
+
+	t.Run("XML pretty", func(t *testing.T) {
+		dataOut, err := encodeStream(senml.MediaTypeSensmlXML, referencePack(), SetPrettyPrint)
+		if err != nil {
+			t.Fatalf("Encoding error: %s", err)
+		}
+		if string(dataOut) != xmlStringPretty {
+			t.Logf("Expected:\n'%s'", xmlStringPretty)
+			t.Fatalf("Got:\n'%s'", dataOut)
+		}
+	})
+
+	t.Run("CBOR", func(t *testing.T) {
+		dataOut, err := encodeStream(senml.MediaTypeSensmlCBOR, referencePack())
+		if err != nil {
+			t.Fatalf("Encoding error: %s", err)
+		}
+		if dataOut[0] != 0x9f || dataOut[len(dataOut)-1] != 0xff {
+			t.Fatalf("Not an indefinite-length array: %x", dataOut)
+		}
+
+		pack, err := DecodeCBOR(dataOut)
+		if err != nil {
+			t.Fatalf("Error decoding: %s", err)
+		}
+		if err := compareFields(pack, referencePack()); err != nil {
+			t.Fatalf("Error matching records: %s", err)
+		}
+	})
+
+	t.Run("empty pack", func(t *testing.T) {
+		for mediaType, expected := range map[string]string{
+			senml.MediaTypeSensmlJSON: `[]`,
+			senml.MediaTypeSensmlCBOR: "\x9f\xff",
+			senml.MediaTypeSensmlXML:  `<sensml xmlns="urn:ietf:params:xml:ns:senml"></sensml>`,
+		} {
+			dataOut, err := encodeStream(mediaType, nil)
+			if err != nil {
+				t.Fatalf("Encoding error: %s", err)
+			}
+			if string(dataOut) != expected {
+				t.Fatalf("Unexpected empty %s pack: %q", mediaType, dataOut)
+			}
+		}
+	})
+
+	t.Run("incremental flush", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := bufio.NewWriter(&buf)
+		e, err := NewStreamEncoder(senml.MediaTypeSensmlJSON, w)
+		if err != nil {
+			t.Fatalf("Error creating encoder: %s", err)
+		}
+		err = e.Encode(referencePack()[1])
+		if err != nil {
+			t.Fatalf("Encoding error: %s", err)
+		}
+		if buf.String() != `[{"n":"room","t":-1,"vs":"kitchen"}` {
+			t.Fatalf("Record was not flushed. Got: '%s'", buf.String())
+		}
+	})
+
+	t.Run("encode after close", func(t *testing.T) {
+		var buf bytes.Buffer
+		e, err := NewStreamEncoder(senml.MediaTypeSensmlCBOR, &buf)
+		if err != nil {
+			t.Fatalf("Error creating encoder: %s", err)
+		}
+		e.Close()
+		err = e.Encode(referencePack()[1])
+		if err == nil {
+			t.Fatalf("No error for encoding after close")
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		ref := referencePack(true)
+		ref.Normalize()
+
+		for _, mediaType := range []string{senml.MediaTypeSensmlJSON, senml.MediaTypeSensmlCBOR, senml.MediaTypeSensmlXML} {
+			dataOut, err := encodeStream(mediaType, referencePack(true))
+			if err != nil {
+				t.Fatalf("Encoding error: %s", err)
+			}
+			pack, err := decodeStream(mediaType, dataOut)
+			if err != nil {
+				t.Fatalf("Error decoding: %s", err)
+			}
+			if err := compareFields(pack, ref); err != nil {
+				t.Fatalf("Error matching %s records: %s", mediaType, err)
+			}
+		}
+	})
+
+	t.Run("unsupported media type", func(t *testing.T) {
+		_, err := NewStreamEncoder(senml.MediaTypeCustomSensmlCSV, &bytes.Buffer{})
+		if err == nil {
+			t.Fatalf("No error for unsupported media type")
+		}
+	})
+}
+
+// EXAMPLES
+
+func ExampleStreamEncoder() {
+	encoder, err := NewStreamEncoder(senml.MediaTypeSensmlJSON, os.Stdout, SetPrettyPrint)
+	if err != nil {
+		panic(err) // handle the error
+	}
+
+	for i, v := range []float64{23.5, 23.6} {
+		value := v
+		err = encoder.Encode(senml.Record{Name: "room1/temp", Unit: senml.UnitCelsius, Time: float64(1276020076 + i), Value: &value})
+		if err != nil {
+			panic(err) // handle the error
+		}
+	}
+
+	err = encoder.Close()
+	if err != nil {
+		panic(err) // handle the error
+	}
+	// Output:
+	// [
+	//   {"n":"room1/temp","u":"Cel","t":1276020076,"v":23.5},
+	//   {"n":"room1/temp","u":"Cel","t":1276020077,"v":23.6}
+	// ]
+}
@@ -0,0 +1,193 @@
+package senml
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Compact returns a copy of the SenML Pack in a compact form, using base fields to shorten the records.
+// It is the inverse of Normalize: normalizing the compacted pack gives back the resolved records.
+//
+// The records are resolved before compacting, keeping relative times relative. The base fields are chosen as follows:
+//   - BaseName is the longest common prefix of the names, ending in a ':', '/' or '.' separator, or the name itself if all names are equal.
+//   - BaseUnit is the most common unit, if every record has a unit.
+//   - BaseTime, BaseValue and BaseSum are set when they shorten the textual representation of the numbers
+//     and adding them to the relative numbers gives back the exact resolved numbers.
+//   - BaseVersion is only kept in the first record.
+//
+// Compact must be called on a validated pack only.
+func (p Pack) Compact() Pack {
+	c := p.Normalized(SetKeepRelativeTime)
+	if len(c) < 2 {
+		return c
+	}
+
+	c.compactName()
+	c.compactUnit()
+	c.compactVersion()
+	c.compactTime()
+	c.compactValues()
+	return c
+}
+
+func (p Pack) compactName() {
+	prefix := p[0].Name
+	for _, r := range p[1:] {
+		i := 0
+		for i < len(prefix) && i < len(r.Name) && prefix[i] == r.Name[i] {
+			i++
+		}
+		prefix = prefix[:i]
+	}
+	for _, r := range p {
+		if r.Name != prefix {
+			// names are not all equal
+			prefix = prefix[:strings.LastIndexAny(prefix, ":/.")+1]
+			break
+		}
+	}
+	if prefix == "" {
+		return
+	}
+
+	p[0].BaseName = prefix
+	for i := range p {
+		p[i].Name = p[i].Name[len(prefix):]
+	}
+}
+
+func (p Pack) compactUnit() {
+	counts := make(map[string]int)
+	unit := ""
+	for _, r := range p {
+		if r.Unit == "" {
+			// a base unit would be applied to this record
+			return
+		}
+		counts[r.Unit]++
+		if counts[r.Unit] > counts[unit] || (counts[r.Unit] == counts[unit] && r.Unit < unit) {
+			unit = r.Unit
+		}
+	}
+	if counts[unit] < 2 {
+		return
+	}
+
+	p[0].BaseUnit = unit
+	for i := range p {
+		if p[i].Unit == unit {
+			p[i].Unit = ""
+		}
+	}
+}
+
+func (p Pack) compactVersion() {
+	for i := 1; i < len(p); i++ {
+		if p[i].BaseVersion != nil && p[0].BaseVersion != nil && *p[i].BaseVersion == *p[0].BaseVersion {
+			p[i].BaseVersion = nil
+		}
+	}
+}
+
+func (p Pack) compactTime() {
+	times := make([]float64, len(p))
+	for i, r := range p {
+		if r.Time < pivot {
+			// relative times are not resolved
+			return
+		}
+		times[i] = r.Time
+	}
+
+	base, ok := compactBase(times)
+	if !ok {
+		return
+	}
+	p[0].BaseTime = base
+	for i := range p {
+		p[i].Time -= base
+	}
+}
+
+func (p Pack) compactValues() {
+	values := make([]float64, len(p))
+	sums := make([]float64, len(p))
+	hasValues, hasSums := true, true
+	for i, r := range p {
+		if r.Value == nil {
+			// a base value would be applied to this record
+			hasValues = false
+		} else {
+			values[i] = *r.Value
+		}
+		if r.Sum == nil {
+			hasSums = false
+		} else {
+			sums[i] = *r.Sum
+		}
+	}
+
+	if hasValues {
+		if base, ok := compactBase(values); ok {
+			p[0].BaseValue = &base
+			for i := range p {
+				*p[i].Value -= base
+			}
+		}
+	}
+	if hasSums {
+		if base, ok := compactBase(sums); ok {
+			p[0].BaseSum = &base
+			for i := range p {
+				*p[i].Sum -= base
+			}
+		}
+	}
+}
+
+// compactBase returns the base which shortens the textual representation of the numbers the most, if any.
+// The candidates are the first, the smallest and the largest numbers.
+// A candidate is dismissed if adding it to any of the relative numbers does not give back the exact number.
+func compactBase(numbers []float64) (base float64, ok bool) {
+	best := 0
+	for _, n := range numbers {
+		best += numberLength(n)
+	}
+
+	min, max := numbers[0], numbers[0]
+	for _, n := range numbers {
+		if n < min {
+			min = n
+		}
+		if n > max {
+			max = n
+		}
+	}
+
+	for _, candidate := range []float64{numbers[0], min, max} {
+		if candidate == 0 {
+			// a zero base is ignored
+			continue
+		}
+		length := numberLength(candidate)
+		for _, n := range numbers {
+			if candidate+(n-candidate) != n {
+				length = -1
+				break
+			}
+			length += numberLength(n - candidate)
+		}
+		if length >= 0 && length < best {
+			best, base, ok = length, candidate, true
+		}
+	}
+	return base, ok
+}
+
+// numberLength returns the length of the shortest textual representation of the number, zero for omitted zeros
+func numberLength(f float64) int {
+	if f == 0 {
+		return 0
+	}
+	return len(strconv.FormatFloat(f, 'g', -1, 64))
+}
@@ -0,0 +1,102 @@
+package senml
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCompact(t *testing.T) {
+
+	t.Run("round trip", func(t *testing.T) {
+		for _, p := range []Pack{referencePack(true), referencePackFloats(), referencePackFloats()[:1], {}} {
+			if len(p) > 0 && p[0].BaseTime < pivot {
+				p[0].BaseTime += 1276020076
+			}
+			resolved := p.Clone()
+			resolved.Normalize()
+
+			compacted := p.Compact()
+			if err := compacted.Validate(); err != nil && len(p) > 0 {
+				t.Fatalf("Compacted pack is not valid: %s: %s", err, stringifyPack(compacted))
+			}
+			compacted.Normalize()
+			if !reflect.DeepEqual(compacted, resolved) {
+				t.Fatalf("Expected: %s. Got: %s", stringifyPack(resolved), stringifyPack(compacted))
+			}
+		}
+	})
+
+	t.Run("base fields", func(t *testing.T) {
+		var p Pack
+		err := json.Unmarshal([]byte(`[
+			{"n":"urn:dev:ow:10e2073a0108006:voltage","u":"V","t":1276020076,"v":120.5},
+			{"n":"urn:dev:ow:10e2073a0108006:current","u":"A","t":1276020071,"v":1.25},
+			{"n":"urn:dev:ow:10e2073a0108006:current","u":"A","t":1276020072,"v":1.5}]`), &p)
+		if err != nil {
+			t.Fatalf("Error decoding: %s", err)
+		}
+
+		compacted := p.Compact()
+		expected := `[{"bn":"urn:dev:ow:10e2073a0108006:","bt":1276020071,"bu":"A","n":"voltage","u":"V","t":5,"v":120.5},` +
+			`{"n":"current","v":1.25},{"n":"current","t":1,"v":1.5}]`
+		if stringifyPack(compacted) != expected {
+			t.Fatalf("Expected: %s. Got: %s", expected, stringifyPack(compacted))
+		}
+		if stringifyPack(p)[:10] != `[{"n":"urn` {
+			t.Fatalf("Original pack was modified: %s", stringifyPack(p))
+		}
+	})
+
+	t.Run("equal names", func(t *testing.T) {
+		v1, v2 := 1.0, 2.0
+		compacted := Pack{{Name: "dev/temp", Value: &v1}, {Name: "dev/temp", Value: &v2}}.Compact()
+		if compacted[0].BaseName != "dev/temp" || compacted[0].Name != "" || compacted[1].Name != "" {
+			t.Fatalf("Unexpected names: %s", stringifyPack(compacted))
+		}
+	})
+
+	t.Run("no common prefix", func(t *testing.T) {
+		v := 1.0
+		compacted := Pack{{Name: "temp1", Value: &v}, {Name: "temp2", StringValue: "on"}}.Compact()
+		if compacted[0].BaseName != "" || compacted[0].Name != "temp1" {
+			t.Fatalf("Unexpected names: %s", stringifyPack(compacted))
+		}
+		if compacted[0].BaseValue != nil {
+			t.Fatalf("Base value for records without values: %s", stringifyPack(compacted))
+		}
+	})
+
+	t.Run("base value and sum", func(t *testing.T) {
+		v1, v2, v3 := 1000.0, 1001.0, 1002.0
+		s1, s2, s3 := 50000.5, 60000.5, 70000.5
+		compacted := Pack{{Name: "a", Value: &v1, Sum: &s1}, {Name: "a", Value: &v2, Sum: &s2}, {Name: "a", Value: &v3, Sum: &s3}}.Compact()
+		expected := `[{"bn":"a","bv":1000,"bs":50000.5,"v":0,"s":0},{"v":1,"s":10000},{"v":2,"s":20000}]`
+		if stringifyPack(compacted) != expected {
+			t.Fatalf("Expected: %s. Got: %s", expected, stringifyPack(compacted))
+		}
+	})
+
+	t.Run("inexact base", func(t *testing.T) {
+		v1, v2 := 0.1, 0.7
+		compacted := Pack{{Name: "a", Value: &v1}, {Name: "b", Value: &v2}}.Compact()
+		if compacted[0].BaseValue != nil {
+			t.Fatalf("Base value without exact round trip: %s", stringifyPack(compacted))
+		}
+	})
+
+	t.Run("missing unit", func(t *testing.T) {
+		v := 1.0
+		compacted := Pack{{Name: "a", Unit: "V", Value: &v}, {Name: "b", Value: &v}, {Name: "c", Unit: "V", Value: &v}}.Compact()
+		if compacted[0].BaseUnit != "" {
+			t.Fatalf("Base unit for records without unit: %s", stringifyPack(compacted))
+		}
+	})
+
+	t.Run("base version", func(t *testing.T) {
+		compacted := referencePackFloats().Compact()
+		if compacted[0].BaseVersion == nil || *compacted[0].BaseVersion != 5 || compacted[1].BaseVersion != nil {
+			t.Fatalf("Unexpected base versions: %s", stringifyPack(compacted))
+		}
+	})
+}
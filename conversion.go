@@ -0,0 +1,113 @@
+package senml
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned by unit conversion, usable with errors.Is
+var (
+	ErrIncompatibleUnits = errors.New("incompatible units")
+	ErrNotResolved       = errors.New("record with base value or base sum must be resolved")
+)
+
+// conversion converts a value as: scale * value + offset
+type conversion struct {
+	scale, offset float64
+}
+
+// rootConversion returns the root unit which the unit converts to through the Units registry, and the conversion to it.
+// If stop is given, the chain also ends at the first unit for which it returns true.
+func rootConversion(symbol string, stop func(Unit) bool) (string, conversion, error) {
+	c := conversion{scale: 1}
+	for visited := 0; ; visited++ {
+		u, found := Units.Lookup(symbol)
+		if !found {
+			return "", c, fmt.Errorf("%w: %s", ErrUnknownUnit, symbol)
+		}
+		if u.ConvertsTo == "" || (stop != nil && stop(u)) {
+			return symbol, c, nil
+		}
+		if visited == 16 {
+			return "", c, fmt.Errorf("%w: conversion loop at %s", ErrIncompatibleUnits, symbol)
+		}
+		c = conversion{scale: u.Scale * c.scale, offset: u.Scale*c.offset + u.Offset}
+		symbol = u.ConvertsTo
+	}
+}
+
+// ConvertUnit returns a copy of the record with the value and sum converted to the target unit, using the Units registry.
+// The unit of the record is the Unit field, or the BaseUnit of the record if the Unit is empty.
+// Units are compatible if they convert to the same unit, e.g. degF to Cel to K.
+// Sums are not converted between units with an offset, e.g. Cel and K, as the integral of such values has no common zero.
+// Records with a base value or base sum must be resolved first; see Normalize.
+func ConvertUnit(r Record, target string) (Record, error) {
+	r = Pack{r}.Clone()[0]
+	unit := r.Unit
+	if unit == "" {
+		unit = r.BaseUnit
+	}
+	if unit == "" {
+		return r, fmt.Errorf("%w: record has no unit", ErrUnknownUnit)
+	}
+	if unit == target {
+		return r, nil
+	}
+	if r.BaseValue != nil || r.BaseSum != nil {
+		return r, ErrNotResolved
+	}
+
+	from, fromRoot, err := rootConversion(unit, nil)
+	if err != nil {
+		return r, err
+	}
+	to, toRoot, err := rootConversion(target, nil)
+	if err != nil {
+		return r, err
+	}
+	if from != to {
+		return r, fmt.Errorf("%w: %s and %s", ErrIncompatibleUnits, unit, target)
+	}
+
+	convert := func(v float64) float64 {
+		return (fromRoot.scale*v + fromRoot.offset - toRoot.offset) / toRoot.scale
+	}
+	if r.Sum != nil {
+		if fromRoot.offset != toRoot.offset {
+			return r, fmt.Errorf("%w: sum cannot be converted from %s to %s with an offset", ErrIncompatibleUnits, unit, target)
+		}
+		*r.Sum = fromRoot.scale * *r.Sum / toRoot.scale
+	}
+	if r.Value != nil {
+		*r.Value = convert(*r.Value)
+	}
+	r.Unit = target
+	return r, nil
+}
+
+// PrimaryUnit returns the recommended primary unit for the given unit, following the conversions of secondary
+// and NOT RECOMMENDED units in the Units registry, e.g. km/h to m/s and degF to Cel.
+func PrimaryUnit(unit string) (string, error) {
+	primary, _, err := rootConversion(unit, func(u Unit) bool { return !u.Secondary && u.Recommended })
+	return primary, err
+}
+
+// ToPrimaryUnits returns a resolved copy of the pack with values and sums converted to recommended primary units; see PrimaryUnit.
+// Relative times are kept relative. Records without a unit are not converted.
+func (p Pack) ToPrimaryUnits() (Pack, error) {
+	resolved := p.Normalized(SetKeepRelativeTime)
+	for i, r := range resolved {
+		if r.Unit == "" {
+			continue
+		}
+		primary, err := PrimaryUnit(r.Unit)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", i, err)
+		}
+		resolved[i], err = ConvertUnit(r, primary)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", i, err)
+		}
+	}
+	return resolved, nil
+}
@@ -0,0 +1,135 @@
+package senml
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestConvertUnit(t *testing.T) {
+	float := func(f float64) *float64 { return &f }
+	near := func(a, b float64) bool { return math.Abs(a-b) <= 1e-9*math.Max(1, math.Abs(b)) }
+
+	tests := []struct {
+		from   string
+		to     string
+		value  float64
+		result float64
+	}{
+		{"degF", "Cel", 212, 100},
+		{"degF", "K", 32, 273.15},
+		{"Cel", "K", 23.1, 296.25},
+		{"K", "degF", 0, -459.67},
+		{"kWh", "J", 1.5, 5400000},
+		{"kWh", "Wh", 1.5, 1500},
+		{"km/h", "m/s", 36, 10},
+		{"m/s", "km/h", 10, 36},
+		{"mm/h", "m/h", 1000, 1},
+		{"dBm", "dBW", 30, 0},
+		{"%", "ppm", 1, 10000},
+		{"g", "kg", 500, 0.5},
+		{"ms", "s", 1500, 1.5},
+	}
+	for _, test := range tests {
+		t.Run(test.from+" to "+test.to, func(t *testing.T) {
+			r := Record{Name: "dev", Unit: test.from, Value: float(test.value)}
+			converted, err := ConvertUnit(r, test.to)
+			if err != nil {
+				t.Fatalf("Error converting: %s", err)
+			}
+			if converted.Unit != test.to || !near(*converted.Value, test.result) {
+				t.Fatalf("Expected %v %s. Got %v %s", test.result, test.to, *converted.Value, converted.Unit)
+			}
+			if *r.Value != test.value || r.Unit != test.from {
+				t.Fatalf("Original record was modified")
+			}
+		})
+	}
+
+	t.Run("sum", func(t *testing.T) {
+		converted, err := ConvertUnit(Record{Unit: "kWh", Value: float(1), Sum: float(2)}, "Wh")
+		if err != nil {
+			t.Fatalf("Error converting: %s", err)
+		}
+		if !near(*converted.Sum, 2000) || !near(*converted.Value, 1000) {
+			t.Fatalf("Unexpected conversion: %v, %v", *converted.Value, *converted.Sum)
+		}
+	})
+
+	t.Run("sum with offset", func(t *testing.T) {
+		_, err := ConvertUnit(Record{Unit: "Cel", Sum: float(2)}, "K")
+		if !errors.Is(err, ErrIncompatibleUnits) {
+			t.Fatalf("Expected incompatible units error. Got: %v", err)
+		}
+	})
+
+	t.Run("base unit", func(t *testing.T) {
+		converted, err := ConvertUnit(Record{BaseUnit: "kW", Value: float(2)}, "W")
+		if err != nil || converted.Unit != "W" || *converted.Value != 2000 {
+			t.Fatalf("Unexpected conversion: %+v, %v", converted, err)
+		}
+	})
+
+	t.Run("incompatible units", func(t *testing.T) {
+		_, err := ConvertUnit(Record{Unit: "km/h", Value: float(1)}, "Cel")
+		if !errors.Is(err, ErrIncompatibleUnits) {
+			t.Fatalf("Expected incompatible units error. Got: %v", err)
+		}
+	})
+
+	t.Run("unknown units", func(t *testing.T) {
+		for _, r := range []Record{{Unit: "mph", Value: float(1)}, {Value: float(1)}} {
+			_, err := ConvertUnit(r, "m/s")
+			if !errors.Is(err, ErrUnknownUnit) {
+				t.Fatalf("Expected unknown unit error. Got: %v", err)
+			}
+		}
+		_, err := ConvertUnit(Record{Unit: "m/s", Value: float(1)}, "mph")
+		if !errors.Is(err, ErrUnknownUnit) {
+			t.Fatalf("Expected unknown unit error. Got: %v", err)
+		}
+	})
+
+	t.Run("not resolved", func(t *testing.T) {
+		_, err := ConvertUnit(Record{Unit: "kW", BaseValue: float(1), Value: float(1)}, "W")
+		if !errors.Is(err, ErrNotResolved) {
+			t.Fatalf("Expected not resolved error. Got: %v", err)
+		}
+	})
+}
+
+func TestToPrimaryUnits(t *testing.T) {
+	float := func(f float64) *float64 { return &f }
+	p := Pack{
+		{BaseName: "dev/", BaseUnit: "km/h", Name: "speed", Value: float(36), Time: -5},
+		{Name: "temp", Unit: "degF", Value: float(32)},
+		{Name: "temp2", Unit: "Cel", Value: float(20)},
+		{Name: "label", StringValue: "kitchen", Unit: "%"},
+	}
+
+	converted, err := p.ToPrimaryUnits()
+	if err != nil {
+		t.Fatalf("Error converting: %s", err)
+	}
+	expected := []struct {
+		unit  string
+		value float64
+	}{{"m/s", 10}, {"Cel", 0}, {"Cel", 20}}
+	for i, e := range expected {
+		if converted[i].Unit != e.unit || math.Abs(*converted[i].Value-e.value) > 1e-9 {
+			t.Fatalf("Expected %v %s for record %d. Got %v %s", e.value, e.unit, i, *converted[i].Value, converted[i].Unit)
+		}
+	}
+	if converted[3].Unit != "/" || converted[3].StringValue != "kitchen" {
+		t.Fatalf("Unexpected string record: %s", stringifyPack(converted))
+	}
+	if converted[0].Time != -5 || converted[0].Name != "dev/speed" {
+		t.Fatalf("Pack was not resolved with relative times: %s", stringifyPack(converted))
+	}
+
+	p[2].Unit = "mph"
+	_, err = p.ToPrimaryUnits()
+	if !errors.Is(err, ErrUnknownUnit) {
+		t.Fatalf("Expected unknown unit error. Got: %v", err)
+	}
+}
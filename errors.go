@@ -0,0 +1,74 @@
+package senml
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Kinds of violations reported by Pack.Validate and Pack.ValidateAll, usable with errors.Is
+var (
+	ErrEmptyName          = errors.New("empty name")
+	ErrInvalidName        = errors.New("invalid name: must begin with alphanumeric and contain alphanumeric or one of - : . / _")
+	ErrInvalidVersion     = errors.New("base version is not a positive integer")
+	ErrUnsupportedVersion = errors.New("unsupported base version")
+	ErrVersionChange      = errors.New("unallowed version change")
+	ErrNotFinite          = errors.New("number is not finite")
+	ErrInvalidUTF8        = errors.New("string is not valid UTF-8")
+	ErrInvalidDataValue   = errors.New("data value is not base64url encoded without padding")
+	ErrTooManyValues      = errors.New("too many values in single record")
+	ErrSumWithNonFloat    = errors.New("sum together with non-float value in a single record")
+	ErrNoValue            = errors.New("no value or sum")
+	ErrMustUnderstand     = errors.New("must-understand field is not understood")
+	ErrExtensionConflict  = errors.New("extension label conflicts with a SenML field")
+	ErrUnknownUnit        = errors.New("unit is not registered")
+)
+
+// ValidationError is a violation found in a record of a SenML Pack
+type ValidationError struct {
+	// Index of the record in the pack
+	Index int
+	// Field is the SenML label of the invalid field, e.g. "bver" or "n"
+	Field string
+	// Err is the kind of violation, e.g. ErrInvalidName
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("record %d, field %s: %s", e.Index, e.Field, e.Err)
+}
+
+// Unwrap returns the kind of violation
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors is a list of violations found in a SenML Pack
+type ValidationErrors []*ValidationError
+
+func (errs ValidationErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i := range errs {
+		messages[i] = errs[i].Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Is reports whether any of the violations matches the target
+func (errs ValidationErrors) Is(target error) bool {
+	for _, err := range errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Unwrap returns the violations
+func (errs ValidationErrors) Unwrap() []error {
+	unwrapped := make([]error, len(errs))
+	for i := range errs {
+		unwrapped[i] = errs[i]
+	}
+	return unwrapped
+}
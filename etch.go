@@ -0,0 +1,96 @@
+package senml
+
+// SenML FETCH and PATCH: https://tools.ietf.org/html/rfc8790
+//
+// Records of a FETCH or PATCH pack identify target records by their resolved name, and their resolved time if any.
+// A PATCH record without a value or sum is a deletion record, encoded with a null value ("v": null) in the
+// senml-etch media types. FETCH records have no value, and are encoded like any other record.
+
+// selects reports whether the resolved selector record identifies the resolved target record
+func selects(selector, target Record) bool {
+	return selector.Name == target.Name && (selector.Time == 0 || selector.Time == target.Time)
+}
+
+// isDeletion reports whether the patch record has no value, i.e. a null value
+func isDeletion(r Record) bool {
+	return r.Kind() == KindNone
+}
+
+// EtchRecord is a record of a SenML PATCH pack. It is encoded like a Record in JSON and CBOR,
+// with a null value if it is a deletion record. Records of FETCH packs are encoded as Record.
+type EtchRecord Record
+
+// MarshalJSON encodes the record as a JSON object, with "v": null for deletion records
+func (r EtchRecord) MarshalJSON() ([]byte, error) {
+	return Record(r).marshalJSON(isDeletion(Record(r)))
+}
+
+// MarshalCBOR encodes the record as a CBOR map, with a null value for deletion records
+func (r EtchRecord) MarshalCBOR() ([]byte, error) {
+	return Record(r).marshalCBOR(isDeletion(Record(r)))
+}
+
+// Fetch returns the resolved records of the target which are identified by the records of the selector,
+// in the order of the selector: https://tools.ietf.org/html/rfc8790#section-5
+// Each target record is returned at most once. Relative times are kept relative.
+func Fetch(target, selector Pack) Pack {
+	resolvedTarget := target.Normalized(SetKeepRelativeTime)
+	selected := make([]bool, len(resolvedTarget))
+	var result Pack
+	for _, s := range selector.Normalized(SetKeepRelativeTime) {
+		for i, r := range resolvedTarget {
+			if !selected[i] && selects(s, r) {
+				selected[i] = true
+				result = append(result, r)
+			}
+		}
+	}
+	return result
+}
+
+// ApplyPatch returns a resolved copy of the target with the records of the patch applied in order:
+// https://tools.ietf.org/html/rfc8790#section-6
+//   - A deletion record, i.e. without a value or sum, removes the target records it identifies.
+//   - Other records replace the target records they identify. The target time is kept if the patch record has none.
+//   - Other records identifying no target record are appended to the target.
+//
+// The target is not modified. Relative times are kept relative.
+// The error is a ValidationError for the first patch record with an invalid name or values.
+func ApplyPatch(target, patch Pack) (Pack, error) {
+	result := target.Normalized(SetKeepRelativeTime)
+	for i, p := range patch.Normalized(SetKeepRelativeTime) {
+		if err := ValidateName(p.Name); err != nil {
+			return nil, &ValidationError{Index: i, Field: "n", Err: err}
+		}
+		deletion := isDeletion(p)
+		if !deletion {
+			if errs := (Pack{p}).validate(false); len(errs) != 0 {
+				errs[0].Index = i
+				return nil, errs[0]
+			}
+		}
+
+		matched := false
+		patched := result[:0:0]
+		for _, r := range result {
+			if !selects(p, r) {
+				patched = append(patched, r)
+				continue
+			}
+			matched = true
+			if deletion {
+				continue
+			}
+			replacement := Pack{p}.Clone()[0]
+			if replacement.Time == 0 {
+				replacement.Time = r.Time
+			}
+			patched = append(patched, replacement)
+		}
+		if !matched && !deletion {
+			patched = append(patched, Pack{p}.Clone()[0])
+		}
+		result = patched
+	}
+	return result, nil
+}
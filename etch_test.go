@@ -0,0 +1,127 @@
+package senml
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+func TestFetch(t *testing.T) {
+	float := func(f float64) *float64 { return &f }
+	target := Pack{
+		{BaseName: "urn:dev:ow:10e2073a01080063:", Name: "temp", Unit: UnitCelsius, Value: float(23.1), Time: 1320078429},
+		{Name: "humidity", Unit: UnitRelativeHumidity, Value: float(60), Time: 1320078429},
+		{Name: "temp", Unit: UnitCelsius, Value: float(23.5), Time: 1320078430},
+	}
+
+	t.Run("by name", func(t *testing.T) {
+		result := Fetch(target, Pack{{BaseName: "urn:dev:ow:10e2073a01080063:", Name: "humidity"}, {Name: "temp"}})
+		if len(result) != 3 || result[0].Name != "urn:dev:ow:10e2073a01080063:humidity" || *result[2].Value != 23.5 {
+			t.Fatalf("Unexpected records: %s", stringifyPack(result))
+		}
+	})
+
+	t.Run("by name and time", func(t *testing.T) {
+		result := Fetch(target, Pack{{Name: "urn:dev:ow:10e2073a01080063:temp", Time: 1320078430}})
+		if len(result) != 1 || *result[0].Value != 23.5 {
+			t.Fatalf("Unexpected records: %s", stringifyPack(result))
+		}
+	})
+
+	t.Run("once per record", func(t *testing.T) {
+		selector := Pack{{Name: "urn:dev:ow:10e2073a01080063:temp"}, {Name: "urn:dev:ow:10e2073a01080063:temp", Time: 1320078429}}
+		if result := Fetch(target, selector); len(result) != 2 {
+			t.Fatalf("Unexpected records: %s", stringifyPack(result))
+		}
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		if result := Fetch(target, Pack{{Name: "pressure"}}); len(result) != 0 {
+			t.Fatalf("Unexpected records: %s", stringifyPack(result))
+		}
+	})
+}
+
+func TestApplyPatch(t *testing.T) {
+	float := func(f float64) *float64 { return &f }
+	target := Pack{
+		{BaseName: "dev/", Name: "temp", Unit: UnitCelsius, Value: float(23.1), Time: -10},
+		{Name: "humidity", Unit: UnitRelativeHumidity, Value: float(60), Time: -10},
+		{Name: "temp", Unit: UnitCelsius, Value: float(23.5)},
+	}
+
+	t.Run("replace, delete and add", func(t *testing.T) {
+		patch := Pack{
+			{BaseName: "dev/", Name: "humidity", Unit: UnitRelativeHumidity, Value: float(61)},
+			{Name: "temp", Time: -10},
+			{Name: "status", StringValue: "ok"},
+		}
+		result, err := ApplyPatch(target, patch)
+		if err != nil {
+			t.Fatalf("Error patching: %s", err)
+		}
+		if len(result) != 3 {
+			t.Fatalf("Unexpected records: %s", stringifyPack(result))
+		}
+		if result[0].Name != "dev/humidity" || *result[0].Value != 61 || result[0].Time != -10 {
+			t.Fatalf("Record was not replaced: %s", stringifyPack(result))
+		}
+		if result[1].Name != "dev/temp" || *result[1].Value != 23.5 {
+			t.Fatalf("Wrong record was deleted: %s", stringifyPack(result))
+		}
+		if result[2].Name != "dev/status" || result[2].StringValue != "ok" {
+			t.Fatalf("Record was not added: %s", stringifyPack(result))
+		}
+		if target[0].BaseName != "dev/" || *target[1].Value != 60 {
+			t.Fatalf("Target was modified: %s", stringifyPack(target))
+		}
+	})
+
+	t.Run("delete by name", func(t *testing.T) {
+		result, err := ApplyPatch(target, Pack{{Name: "dev/temp"}})
+		if err != nil {
+			t.Fatalf("Error patching: %s", err)
+		}
+		if len(result) != 1 || result[0].Name != "dev/humidity" {
+			t.Fatalf("Unexpected records: %s", stringifyPack(result))
+		}
+	})
+
+	t.Run("invalid patch", func(t *testing.T) {
+		_, err := ApplyPatch(target, Pack{{Name: "dev/temp", Value: float(1)}, {Name: "dev/temp", Value: float(1), StringValue: "on"}})
+		var verr *ValidationError
+		if !errors.As(err, &verr) || verr.Index != 1 || !errors.Is(err, ErrTooManyValues) {
+			t.Fatalf("Expected too many values error for record 1. Got: %v", err)
+		}
+		_, err = ApplyPatch(target, Pack{{Value: float(1)}})
+		if !errors.Is(err, ErrEmptyName) {
+			t.Fatalf("Expected empty name error. Got: %v", err)
+		}
+	})
+}
+
+func TestEtchRecord(t *testing.T) {
+	r := EtchRecord{Name: "humidity", Extensions: map[string]interface{}{"label": "kitchen"}}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("Error encoding: %s", err)
+	}
+	if expected := `{"n":"humidity","v":null,"label":"kitchen"}`; string(b) != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, b)
+	}
+
+	b, err = cbor.Marshal(r)
+	if err != nil {
+		t.Fatalf("Error encoding: %s", err)
+	}
+	var decoded map[interface{}]interface{}
+	if err := cbor.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("Error decoding: %s", err)
+	}
+	if value, found := decoded[uint64(2)]; !found || value != nil || decoded["label"] != "kitchen" || len(decoded) != 3 {
+		t.Fatalf("Unexpected CBOR map: %v", decoded)
+	}
+}
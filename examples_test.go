@@ -1,8 +1,11 @@
 package senml_test
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/farshidtz/senml/v2"
 	"github.com/farshidtz/senml/v2/codec"
 )
 
@@ -60,6 +63,31 @@ func ExamplePack_Normalize() {
 	// ]
 }
 
+func ExamplePack_Normalize_clock() {
+	input := `[{"bn":"room1/temp","u":"Cel","t":-10,"v":23.5},{"u":"Cel","v":23.6}]`
+
+	// decode JSON
+	pack, err := codec.DecodeJSON([]byte(input))
+	if err != nil {
+		panic(err) // handle the error
+	}
+
+	// resolve relative times against a fixed clock
+	now := time.Date(2010, 6, 8, 18, 1, 26, 0, time.UTC)
+	pack.Normalize(senml.SetClock(func() time.Time { return now }))
+
+	dataOut, err := codec.EncodeJSON(pack, codec.SetPrettyPrint)
+	if err != nil {
+		panic(err) // handle the error
+	}
+	fmt.Printf("%s", dataOut)
+	// Output:
+	// [
+	//   {"n":"room1/temp","u":"Cel","t":1276020076,"v":23.5},
+	//   {"n":"room1/temp","u":"Cel","t":1276020086,"v":23.6}
+	// ]
+}
+
 func ExamplePack_Validate() {
 	input := `[{"bn":"room1/ temp","t":1270000040,"v":23.5},{"t":1270000050,"v":23.6}]`
 
@@ -74,14 +102,14 @@ func ExamplePack_Validate() {
 	if err != nil {
 		fmt.Println(err) // handle the error
 	}
-	// Output: invalid name: must begin with alphanumeric and contain alphanumeric or one of - : . / _
+	// Output: record 0, field n: invalid name: must begin with alphanumeric and contain alphanumeric or one of - : . / _
 }
 
-func ExamplePack_Validate2() {
+func ExamplePack_Validate_tooManyValues() {
 	input := `[{"bn":"room1/temp","t":1270000050,"v":23.6,"vs":"cool"}]`
 
 	// decode JSON
-	pack, err := codec.DecodeJSON([]byte(input))
+	pack, err := codec.Decode(senml.MediaTypeSenmlJSON, []byte(input))
 	if err != nil {
 		panic(err) // handle the error
 	}
@@ -91,5 +119,121 @@ func ExamplePack_Validate2() {
 	if err != nil {
 		fmt.Println(err) // handle the error
 	}
-	// Output: too many values in single record
+	// Output: record 0, field v: too many values in single record
+}
+
+func ExamplePack_ValidateAll() {
+	input := `[{"bn":"room1/temp","t":1270000050,"v":23.6,"vs":"cool"},{"n":" hum","t":1270000050,"v":40}]`
+
+	// decode JSON
+	pack, err := codec.DecodeJSON([]byte(input))
+	if err != nil {
+		panic(err) // handle the error
+	}
+
+	// validate the whole SenML Pack
+	err = pack.ValidateAll()
+	if errs, ok := err.(senml.ValidationErrors); ok {
+		for _, e := range errs {
+			fmt.Println(e.Index, e.Field, errors.Is(e, senml.ErrInvalidName))
+		}
+	}
+	// Output:
+	// 0 v false
+	// 1 n true
+}
+
+func ExampleBuilder() {
+	pack, err := senml.NewBuilder().
+		BaseName("urn:dev:ow:10e2073a01080063:").
+		At(time.Unix(1320067464, 0)).
+		Float("temp", senml.UnitCelsius, 23.1).
+		Bool("open", false).
+		Pack()
+	if err != nil {
+		panic(err) // handle the error
+	}
+
+	dataOut, err := codec.EncodeJSON(pack, codec.SetPrettyPrint)
+	if err != nil {
+		panic(err) // handle the error
+	}
+	fmt.Printf("%s", dataOut)
+	// Output:
+	// [
+	//   {"bn":"urn:dev:ow:10e2073a01080063:","n":"temp","u":"Cel","t":1320067464,"v":23.1},
+	//   {"n":"open","t":1320067464,"vb":false}
+	// ]
+}
+
+func ExampleRecord_Kind() {
+	input := `[{"n":"temp","v":23.1},{"n":"label","vs":"Machine Room"},{"n":"open","vb":false},{"n":"nfc-reader","vd":"aGkgCg"}]`
+
+	pack, err := codec.DecodeJSON([]byte(input))
+	if err != nil {
+		panic(err) // handle the error
+	}
+
+	for _, r := range pack {
+		switch r.Kind() {
+		case senml.KindFloat:
+			v, _ := r.Float()
+			fmt.Println(r.Name, "float", v)
+		case senml.KindData:
+			b, _ := r.Data()
+			fmt.Printf("%s data %q\n", r.Name, b)
+		default:
+			fmt.Println(r.Name, r.Kind(), r.Any())
+		}
+	}
+	// Output:
+	// temp float 23.1
+	// label string Machine Room
+	// open bool false
+	// nfc-reader data "hi \n"
+}
+
+func ExamplePack_Select() {
+	input := `[{"bn":"urn:dev:ow:10e2073a01080063:","bt":1.320078429e+09,"n":"temp","u":"Cel","v":23.1},
+		{"n":"humidity","u":"%RH","v":60,"t":1},
+		{"bn":"urn:dev:ow:10e2073a0108006:","n":"temp","u":"Cel","v":25,"t":2}]`
+
+	pack, err := codec.DecodeJSON([]byte(input))
+	if err != nil {
+		panic(err) // handle the error
+	}
+
+	from := time.Unix(1320078430, 0)
+	for _, r := range pack.Select(senml.NamePrefix("urn:dev:ow:10e2073a01080063:"), senml.Between(from, time.Time{})) {
+		fmt.Println(r.Name, *r.Value, r.Unit)
+	}
+	// Output:
+	// urn:dev:ow:10e2073a01080063:humidity 60 %RH
+}
+
+func ExampleMarshal() {
+	type Room struct {
+		Temperature float64 `senml:"temperature,unit=Cel"`
+		Occupied    bool    `senml:"occupied"`
+	}
+
+	pack, err := senml.Marshal(Room{Temperature: 23.1, Occupied: true}, senml.SetBaseName("building/room1/"))
+	if err != nil {
+		panic(err) // handle the error
+	}
+	dataOut, err := codec.EncodeJSON(pack)
+	if err != nil {
+		panic(err) // handle the error
+	}
+	fmt.Printf("%s\n", dataOut)
+
+	var room Room
+	err = senml.Unmarshal(pack, &room)
+	if err != nil {
+		panic(err) // handle the error
+	}
+	fmt.Printf("%+v\n", room)
+	// Output:
+	// [{"bn":"building/room1/","n":"temperature","u":"Cel","v":23.1},{"n":"occupied","vb":true}]
+	// {Temperature:23.1 Occupied:true}
 }
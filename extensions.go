@@ -0,0 +1,328 @@
+package senml
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Must-understand fields: https://tools.ietf.org/html/rfc8428#section-4.4
+var understood = struct {
+	sync.RWMutex
+	labels map[string]bool
+}{labels: make(map[string]bool)}
+
+// RegisterMustUnderstand registers must-understand labels, i.e. labels ending in an underscore, as understood by the application.
+// Records with must-understand fields that are not registered are rejected by the decoders and Pack.Validate.
+func RegisterMustUnderstand(labels ...string) {
+	understood.Lock()
+	defer understood.Unlock()
+	for _, label := range labels {
+		understood.labels[label] = true
+	}
+}
+
+// IsUnderstood reports whether the application understands the extension label.
+// Labels not ending in an underscore are always understood, as they can be safely ignored.
+func IsUnderstood(label string) bool {
+	if !strings.HasSuffix(label, "_") {
+		return true
+	}
+	understood.RLock()
+	defer understood.RUnlock()
+	return understood.labels[label]
+}
+
+// fieldLabels are the labels of the fields defined in RFC8428
+var fieldLabels = []string{"bn", "bt", "bu", "bver", "bv", "bs", "n", "u", "t", "ut", "v", "vs", "vd", "vb", "s"}
+
+// isFieldLabel reports whether the label is that of a field defined in RFC8428
+func isFieldLabel(label string) bool {
+	for _, l := range fieldLabels {
+		if label == l {
+			return true
+		}
+	}
+	return false
+}
+
+// isJSONFieldLabel reports whether the JSON label is decoded as a field defined in RFC8428.
+// encoding/json matches labels case-insensitively, e.g. "V" is decoded as the value.
+func isJSONFieldLabel(label string) bool {
+	for _, l := range fieldLabels {
+		if strings.EqualFold(label, l) {
+			return true
+		}
+	}
+	return false
+}
+
+// isCBORFieldLabel reports whether the CBOR label is that of a field defined in RFC8428, i.e. -6 (bs) to 8 (vd)
+func isCBORFieldLabel(label int64) bool {
+	return label >= -6 && label <= 8
+}
+
+// conflictsWithField reports whether the extension label is decoded as a field defined in RFC8428 in JSON or,
+// being an integer, in CBOR
+func conflictsWithField(label string) bool {
+	if isJSONFieldLabel(label) {
+		return true
+	}
+	i, err := strconv.ParseInt(label, 10, 64)
+	return err == nil && isCBORFieldLabel(i)
+}
+
+func sortedLabels(extensions map[string]interface{}) []string {
+	labels := make([]string, 0, len(extensions))
+	for label := range extensions {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+// checkExtensions returns an error for extensions which cannot be encoded or decoded
+func checkExtensions(extensions map[string]interface{}) error {
+	for _, label := range sortedLabels(extensions) {
+		if conflictsWithField(label) {
+			return fmt.Errorf("%w: %s", ErrExtensionConflict, label)
+		}
+		if !IsUnderstood(label) {
+			return fmt.Errorf("%w: %s", ErrMustUnderstand, label)
+		}
+	}
+	return nil
+}
+
+// record has the fields of Record but none of its methods
+type record Record
+
+// MarshalJSON encodes the record, including its extensions, as a JSON object
+func (r Record) MarshalJSON() ([]byte, error) {
+	return r.marshalJSON(false)
+}
+
+// marshalJSON encodes the record as a JSON object, with a null value if null is set
+func (r Record) marshalJSON(null bool) ([]byte, error) {
+	b, err := json.Marshal(record(r))
+	if err != nil || (len(r.Extensions) == 0 && !null) {
+		return b, err
+	}
+	if err := checkExtensions(r.Extensions); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(b[:len(b)-1])
+	if null {
+		if len(b) > 2 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(`"v":null`)
+	}
+	for i, label := range sortedLabels(r.Extensions) {
+		if i != 0 || len(b) > 2 || null {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(label)
+		value, err := json.Marshal(r.Extensions[label])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON decodes the record from a JSON object, keeping the unknown fields as extensions
+func (r *Record) UnmarshalJSON(b []byte) error {
+	var rec record
+	err := json.Unmarshal(b, &rec)
+	if err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(b, &fields)
+	if err != nil {
+		return err
+	}
+	for label, raw := range fields {
+		if isJSONFieldLabel(label) || strings.EqualFold(label, "_") {
+			continue
+		}
+		var value interface{}
+		err = json.Unmarshal(raw, &value)
+		if err != nil {
+			return err
+		}
+		if rec.Extensions == nil {
+			rec.Extensions = make(map[string]interface{})
+		}
+		rec.Extensions[label] = value
+	}
+	if err := checkExtensions(rec.Extensions); err != nil {
+		return err
+	}
+	*r = Record(rec)
+	return nil
+}
+
+// MarshalCBOR encodes the record, including its extensions, as a CBOR map.
+// Extension labels which are integers are encoded as integer keys.
+func (r Record) MarshalCBOR() ([]byte, error) {
+	return r.marshalCBOR(false)
+}
+
+// marshalCBOR encodes the record as a CBOR map, with a null value if null is set
+func (r Record) marshalCBOR(null bool) ([]byte, error) {
+	b, err := cbor.Marshal(record(r))
+	if err != nil || (len(r.Extensions) == 0 && !null) {
+		return b, err
+	}
+	if err := checkExtensions(r.Extensions); err != nil {
+		return nil, err
+	}
+
+	// replace the map head to account for the null value and extensions
+	var pairs uint64
+	var body []byte
+	switch ai := b[0] & 0x1f; {
+	case ai < 24:
+		pairs, body = uint64(ai), b[1:]
+	case ai == 24:
+		pairs, body = uint64(b[1]), b[2:]
+	default: // at most 16 fields
+		return nil, fmt.Errorf("unexpected CBOR map head: %x", b[0])
+	}
+	pairs += uint64(len(r.Extensions))
+	if null {
+		pairs++
+	}
+	var buf bytes.Buffer
+	buf.Write(cborMapHead(pairs))
+	buf.Write(body)
+	if null {
+		buf.Write([]byte{0x02, 0xf6}) // value label (2) with null
+	}
+	for _, label := range sortedLabels(r.Extensions) {
+		var key interface{} = label
+		if i, err := strconv.ParseInt(label, 10, 64); err == nil {
+			key = i
+		}
+		for _, v := range []interface{}{key, r.Extensions[label]} {
+			item, err := cbor.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(item)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// cborMapHead returns the head of a CBOR map with the given number of pairs
+func cborMapHead(pairs uint64) []byte {
+	const major = 5 << 5
+	switch {
+	case pairs < 24:
+		return []byte{major | byte(pairs)}
+	case pairs <= 0xff:
+		return []byte{major | 24, byte(pairs)}
+	default:
+		return []byte{major | 25, byte(pairs >> 8), byte(pairs)}
+	}
+}
+
+// UnmarshalCBOR decodes the record from a CBOR map, keeping the unknown fields as extensions.
+// Integer keys of unknown fields are converted to decimal labels.
+func (r *Record) UnmarshalCBOR(b []byte) error {
+	var rec record
+	err := cbor.Unmarshal(b, &rec)
+	if err != nil {
+		return err
+	}
+	var fields map[interface{}]cbor.RawMessage
+	err = cbor.Unmarshal(b, &fields)
+	if err != nil {
+		return err
+	}
+	for key, raw := range fields {
+		var label string
+		switch k := key.(type) {
+		case uint64:
+			if k <= math.MaxInt64 && isCBORFieldLabel(int64(k)) {
+				continue
+			}
+			label = strconv.FormatUint(k, 10)
+		case int64:
+			if isCBORFieldLabel(k) {
+				continue
+			}
+			label = strconv.FormatInt(k, 10)
+		case string:
+			label = k
+		default:
+			return fmt.Errorf("unsupported CBOR key type: %T", key)
+		}
+		var value interface{}
+		err = cbor.Unmarshal(raw, &value)
+		if err != nil {
+			return err
+		}
+		if rec.Extensions == nil {
+			rec.Extensions = make(map[string]interface{})
+		}
+		rec.Extensions[label] = value
+	}
+	if err := checkExtensions(rec.Extensions); err != nil {
+		return err
+	}
+	*r = Record(rec)
+	return nil
+}
+
+// MarshalXML encodes the record as an XML element, with extensions as additional attributes
+func (r Record) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if err := checkExtensions(r.Extensions); err != nil {
+		return err
+	}
+	start.Name.Local = "senml"
+	for _, label := range sortedLabels(r.Extensions) {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: label}, Value: fmt.Sprint(r.Extensions[label])})
+	}
+	return e.EncodeElement(record(r), start)
+}
+
+// UnmarshalXML decodes the record from an XML element, keeping the unknown attributes as string extensions
+func (r *Record) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var rec record
+	err := d.DecodeElement(&rec, &start)
+	if err != nil {
+		return err
+	}
+	for _, attr := range start.Attr {
+		if isFieldLabel(attr.Name.Local) || attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+			continue
+		}
+		if rec.Extensions == nil {
+			rec.Extensions = make(map[string]interface{})
+		}
+		rec.Extensions[attr.Name.Local] = attr.Value
+	}
+	if err := checkExtensions(rec.Extensions); err != nil {
+		return err
+	}
+	*r = Record(rec)
+	return nil
+}
@@ -0,0 +1,154 @@
+package senml
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+func TestExtensionsJSON(t *testing.T) {
+	RegisterMustUnderstand("battery_")
+
+	t.Run("round trip", func(t *testing.T) {
+		input := `{"n":"dev","v":1,"battery_":87,"label":"kitchen","pos":{"x":1}}`
+		var r Record
+		err := json.Unmarshal([]byte(input), &r)
+		if err != nil {
+			t.Fatalf("Error decoding: %s", err)
+		}
+		expected := map[string]interface{}{"battery_": 87.0, "label": "kitchen", "pos": map[string]interface{}{"x": 1.0}}
+		if !reflect.DeepEqual(r.Extensions, expected) {
+			t.Fatalf("Expected extensions: %v. Got: %v", expected, r.Extensions)
+		}
+
+		b, err := json.Marshal(r)
+		if err != nil {
+			t.Fatalf("Error encoding: %s", err)
+		}
+		if string(b) != `{"n":"dev","v":1,"battery_":87,"label":"kitchen","pos":{"x":1}}` {
+			t.Fatalf("Unexpected encoding: %s", b)
+		}
+	})
+
+	t.Run("only extensions", func(t *testing.T) {
+		b, err := json.Marshal(Record{Extensions: map[string]interface{}{"label": "kitchen"}})
+		if err != nil {
+			t.Fatalf("Error encoding: %s", err)
+		}
+		if string(b) != `{"label":"kitchen"}` {
+			t.Fatalf("Unexpected encoding: %s", b)
+		}
+	})
+
+	t.Run("unknown must-understand field", func(t *testing.T) {
+		var r Record
+		err := json.Unmarshal([]byte(`{"n":"dev","v":1,"unknown_":1}`), &r)
+		if !errors.Is(err, ErrMustUnderstand) {
+			t.Fatalf("Expected must-understand error. Got: %v", err)
+		}
+	})
+
+	t.Run("conflicting extension", func(t *testing.T) {
+		for _, label := range []string{"v", "V", "BVer", "2", "-6"} {
+			_, err := json.Marshal(Record{Extensions: map[string]interface{}{label: 1}})
+			if !errors.Is(err, ErrExtensionConflict) {
+				t.Fatalf("Expected conflict error for %s. Got: %v", label, err)
+			}
+		}
+	})
+
+	t.Run("case-insensitive field", func(t *testing.T) {
+		var r Record
+		err := json.Unmarshal([]byte(`{"N":"dev","V":3}`), &r)
+		if err != nil {
+			t.Fatalf("Error decoding: %s", err)
+		}
+		if r.Name != "dev" || r.Value == nil || *r.Value != 3 || r.Extensions != nil {
+			t.Fatalf("Unexpected record: %v", r)
+		}
+	})
+}
+
+func TestExtensionsCBOR(t *testing.T) {
+	RegisterMustUnderstand("battery_")
+
+	value := 1.0
+	r := Record{Name: "dev", Value: &value, Extensions: map[string]interface{}{"battery_": uint64(87), "9": "nine", "-7": int64(-7)}}
+	b, err := cbor.Marshal(r)
+	if err != nil {
+		t.Fatalf("Error encoding: %s", err)
+	}
+
+	var fields map[interface{}]interface{}
+	err = cbor.Unmarshal(b, &fields)
+	if err != nil {
+		t.Fatalf("Error decoding map: %s", err)
+	}
+	if fields[uint64(9)] != "nine" || fields[int64(-7)] != int64(-7) || fields["battery_"] != uint64(87) || len(fields) != 5 {
+		t.Fatalf("Unexpected CBOR map: %v", fields)
+	}
+
+	var decoded Record
+	err = cbor.Unmarshal(b, &decoded)
+	if err != nil {
+		t.Fatalf("Error decoding: %s", err)
+	}
+	if !reflect.DeepEqual(decoded, r) {
+		t.Fatalf("Expected: %v. Got: %v", r, decoded)
+	}
+
+	err = cbor.Unmarshal([]byte("\xa1\x68unknown_\x01"), &decoded)
+	if !errors.Is(err, ErrMustUnderstand) {
+		t.Fatalf("Expected must-understand error. Got: %v", err)
+	}
+}
+
+func TestExtensionsXML(t *testing.T) {
+	RegisterMustUnderstand("battery_")
+
+	value := 1.0
+	r := Record{Name: "dev", Value: &value, Extensions: map[string]interface{}{"battery_": 87, "label": "kitchen"}}
+	b, err := xml.Marshal(r)
+	if err != nil {
+		t.Fatalf("Error encoding: %s", err)
+	}
+	if string(b) != `<senml battery_="87" label="kitchen" n="dev" v="1"></senml>` {
+		t.Fatalf("Unexpected encoding: %s", b)
+	}
+
+	var decoded Record
+	err = xml.Unmarshal(b, &decoded)
+	if err != nil {
+		t.Fatalf("Error decoding: %s", err)
+	}
+	expected := map[string]interface{}{"battery_": "87", "label": "kitchen"}
+	if !reflect.DeepEqual(decoded.Extensions, expected) {
+		t.Fatalf("Expected extensions: %v. Got: %v", expected, decoded.Extensions)
+	}
+
+	err = xml.Unmarshal([]byte(`<senml n="dev" v="1" unknown_="1"></senml>`), &decoded)
+	if !errors.Is(err, ErrMustUnderstand) {
+		t.Fatalf("Expected must-understand error. Got: %v", err)
+	}
+}
+
+func TestValidateExtensions(t *testing.T) {
+	RegisterMustUnderstand("battery_")
+
+	value := 1.0
+	pack := Pack{
+		{Name: "dev", Value: &value, Extensions: map[string]interface{}{"battery_": 87, "label": "kitchen"}},
+	}
+	if err := pack.Validate(); err != nil {
+		t.Fatalf("Error for understood extensions: %s", err)
+	}
+
+	pack[0].Extensions["unknown_"] = 1
+	if err := pack.Validate(); !errors.Is(err, ErrMustUnderstand) {
+		t.Fatalf("Expected must-understand error. Got: %v", err)
+	}
+}
@@ -0,0 +1,382 @@
+package senml
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Errors returned by Marshal and Unmarshal, usable with errors.Is
+var (
+	ErrUnsupportedType = errors.New("unsupported type")
+	ErrKindMismatch    = errors.New("value kind does not match the field type")
+	ErrIndexOutOfRange = errors.New("slice index exceeds the number of records")
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	bytesType    = reflect.TypeOf([]byte(nil))
+)
+
+type marshalOptions struct {
+	baseName string
+	baseTime time.Time
+}
+
+// MarshalOption is the function type for setting marshalling options
+type MarshalOption func(*marshalOptions)
+
+// SetBaseName sets the base name of the pack, to which the names of the records are relative
+func SetBaseName(name string) MarshalOption {
+	return func(o *marshalOptions) {
+		o.baseName = name
+	}
+}
+
+// SetBaseTime sets the base time of the pack, i.e. the time of all records
+func SetBaseTime(t time.Time) MarshalOption {
+	return func(o *marshalOptions) {
+		o.baseTime = t
+	}
+}
+
+// fieldTag is the parsed senml struct tag of a field
+type fieldTag struct {
+	name string
+	unit string
+}
+
+// parseTag returns the tag of the struct field, or false if the field is not marshalled.
+// The tag is the name followed by comma-separated options, e.g. `senml:"temperature,unit=Cel"`.
+// Fields without a tag are named after the field.
+func parseTag(f reflect.StructField) (fieldTag, bool) {
+	if f.PkgPath != "" { // unexported
+		return fieldTag{}, false
+	}
+	tag, found := f.Tag.Lookup("senml")
+	if tag == "-" {
+		return fieldTag{}, false
+	}
+	parts := strings.Split(tag, ",")
+	t := fieldTag{name: parts[0]}
+	if !found || t.name == "" {
+		t.name = f.Name
+	}
+	for _, option := range parts[1:] {
+		if strings.HasPrefix(option, "unit=") {
+			t.unit = strings.TrimPrefix(option, "unit=")
+		}
+	}
+	return t, true
+}
+
+func joinName(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "/" + name
+}
+
+// Marshal returns a SenML Pack with a record for each field of the struct v, which may be a pointer.
+// Fields are named by the senml struct tag, e.g. `senml:"temperature,unit=Cel"`, or after the field if untagged,
+// and skipped with `senml:"-"`. The unit of a record is that of its tag.
+//
+// Numbers are encoded as float values, strings as string values, bools as boolean values, and []byte as base64url data values.
+// A time.Time is encoded as a float value in seconds since the Unix epoch and a time.Duration as a float value in seconds,
+// both with the s unit unless tagged with another unit.
+// Nested structs and slice elements are encoded as records with the field name and the struct field name or element index as path,
+// e.g. "sensors/0/temperature". Nil pointers, empty strings and empty []byte are skipped, as records without a value are invalid.
+//
+// The pack is validated; see Pack.Validate.
+func Marshal(v interface{}, options ...MarshalOption) (Pack, error) {
+	o := &marshalOptions{}
+	for _, opt := range options {
+		opt(o)
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct || rv.Type() == timeType {
+		return nil, fmt.Errorf("%w: %T is not a struct", ErrUnsupportedType, v)
+	}
+
+	var p Pack
+	if err := marshalStruct(&p, rv, ""); err != nil {
+		return nil, err
+	}
+	if len(p) == 0 {
+		return p, nil
+	}
+	p[0].BaseName = o.baseName
+	if !o.baseTime.IsZero() {
+		p[0].BaseTime = toSeconds(o.baseTime.Unix(), int64(o.baseTime.Nanosecond()))
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func marshalStruct(p *Pack, rv reflect.Value, path string) error {
+	for i := 0; i < rv.NumField(); i++ {
+		tag, ok := parseTag(rv.Type().Field(i))
+		if !ok {
+			continue
+		}
+		if err := marshalValue(p, rv.Field(i), joinName(path, tag.name), tag.unit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func marshalValue(p *Pack, rv reflect.Value, name, unit string) error {
+	r := Record{Name: name, Unit: unit}
+	switch {
+	case rv.Type() == timeType:
+		t := rv.Interface().(time.Time)
+		v := toSeconds(t.Unix(), int64(t.Nanosecond()))
+		r.Value = &v
+		if unit == "" {
+			r.Unit = UnitSecond
+		}
+	case rv.Type() == durationType:
+		v := durationToSeconds(time.Duration(rv.Int()))
+		r.Value = &v
+		if unit == "" {
+			r.Unit = UnitSecond
+		}
+	case rv.Type() == bytesType:
+		if rv.Len() == 0 {
+			return nil
+		}
+		r.DataValue = base64.RawURLEncoding.EncodeToString(rv.Bytes())
+	default:
+		switch rv.Kind() {
+		case reflect.Ptr:
+			if rv.IsNil() {
+				return nil
+			}
+			return marshalValue(p, rv.Elem(), name, unit)
+		case reflect.Struct:
+			return marshalStruct(p, rv, name)
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < rv.Len(); i++ {
+				if err := marshalValue(p, rv.Index(i), joinName(name, strconv.Itoa(i)), unit); err != nil {
+					return err
+				}
+			}
+			return nil
+		case reflect.Float32, reflect.Float64:
+			v := rv.Float()
+			r.Value = &v
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			v := float64(rv.Int())
+			r.Value = &v
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			v := float64(rv.Uint())
+			r.Value = &v
+		case reflect.Bool:
+			v := rv.Bool()
+			r.BoolValue = &v
+		case reflect.String:
+			if rv.Len() == 0 {
+				return nil
+			}
+			r.StringValue = rv.String()
+		default:
+			return fmt.Errorf("%s: %w: %s", name, ErrUnsupportedType, rv.Type())
+		}
+	}
+	*p = append(*p, r)
+	return nil
+}
+
+// Unmarshal sets the fields of the struct pointed to by v from the records of the SenML Pack, as encoded by Marshal.
+// Records are matched by their resolved name, relative to the base name of the first record. Of records with the same name,
+// the last one is used. Fields without a record are left unchanged, and records without a field are ignored.
+// Slices are extended to fit the largest index of their records, and nil pointers are allocated for their records.
+// The error wraps ErrIndexOutOfRange for indexes not less than the number of records, as Marshal encodes a record per element.
+//
+// Float values are converted to the unit of the field tag, if any; see ConvertUnit.
+// The error wraps ErrKindMismatch for values which do not match or fit the type of their field.
+func Unmarshal(p Pack, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %T is not a pointer to a struct", ErrUnsupportedType, v)
+	}
+	var baseName string
+	if len(p) > 0 {
+		baseName = p[0].BaseName
+	}
+	records := make(map[string]Record, len(p))
+	for _, r := range p.Normalized(SetKeepRelativeTime) {
+		if strings.HasPrefix(r.Name, baseName) {
+			records[strings.TrimPrefix(r.Name, baseName)] = r
+		}
+	}
+	return unmarshalStruct(records, rv.Elem(), "")
+}
+
+// hasRecords reports whether there is a record for the path or a path below it
+func hasRecords(records map[string]Record, path string) bool {
+	if _, found := records[path]; found {
+		return true
+	}
+	for name := range records {
+		if strings.HasPrefix(name, path+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func unmarshalStruct(records map[string]Record, rv reflect.Value, path string) error {
+	for i := 0; i < rv.NumField(); i++ {
+		tag, ok := parseTag(rv.Type().Field(i))
+		if !ok {
+			continue
+		}
+		if err := unmarshalValue(records, rv.Field(i), joinName(path, tag.name), tag.unit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unmarshalValue(records map[string]Record, rv reflect.Value, name, unit string) error {
+	t := rv.Type()
+	if t != timeType && t != bytesType {
+		switch rv.Kind() {
+		case reflect.Ptr:
+			if !hasRecords(records, name) {
+				return nil
+			}
+			if rv.IsNil() {
+				rv.Set(reflect.New(t.Elem()))
+			}
+			return unmarshalValue(records, rv.Elem(), name, unit)
+		case reflect.Struct:
+			return unmarshalStruct(records, rv, name)
+		case reflect.Slice:
+			length := -1
+			for n := range records {
+				if !strings.HasPrefix(n, name+"/") {
+					continue
+				}
+				segment := strings.SplitN(strings.TrimPrefix(n, name+"/"), "/", 2)[0]
+				i, err := strconv.Atoi(segment)
+				if err != nil || i < 0 {
+					continue
+				}
+				// a slice has a record per element, which bounds the index by the number of records
+				if i >= len(records) {
+					return fmt.Errorf("%s: %w: %d", joinName(name, segment), ErrIndexOutOfRange, i)
+				}
+				if i >= length {
+					length = i + 1
+				}
+			}
+			if length > rv.Len() {
+				grown := reflect.MakeSlice(t, length, length)
+				reflect.Copy(grown, rv)
+				rv.Set(grown)
+			}
+			fallthrough
+		case reflect.Array:
+			for i := 0; i < rv.Len(); i++ {
+				if err := unmarshalValue(records, rv.Index(i), joinName(name, strconv.Itoa(i)), unit); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	r, found := records[name]
+	if !found {
+		return nil
+	}
+	mismatch := func() error {
+		return fmt.Errorf("%s: %w: %s value for %s", name, ErrKindMismatch, r.Kind(), t)
+	}
+
+	switch {
+	case t == bytesType:
+		if r.Kind() != KindData {
+			return mismatch()
+		}
+		b, err := r.Data()
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		rv.SetBytes(b)
+		return nil
+	case rv.Kind() == reflect.String:
+		if r.Kind() != KindString {
+			return mismatch()
+		}
+		rv.SetString(r.StringValue)
+		return nil
+	case rv.Kind() == reflect.Bool:
+		if r.Kind() != KindBool {
+			return mismatch()
+		}
+		rv.SetBool(*r.BoolValue)
+		return nil
+	}
+
+	if r.Kind() != KindFloat {
+		return mismatch()
+	}
+	if t == timeType || t == durationType {
+		if unit == "" {
+			unit = UnitSecond
+		}
+	}
+	if unit != "" && r.Unit != "" && r.Unit != unit {
+		converted, err := ConvertUnit(r, unit)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		r = converted
+	}
+	f := *r.Value
+
+	switch {
+	case t == timeType:
+		sec, nsec := splitSeconds(f)
+		rv.Set(reflect.ValueOf(time.Unix(sec, nsec)))
+	case t == durationType:
+		rv.SetInt(int64(secondsToDuration(f)))
+	default:
+		switch rv.Kind() {
+		case reflect.Float32, reflect.Float64:
+			if rv.OverflowFloat(f) {
+				return fmt.Errorf("%s: %w: %v overflows %s", name, ErrKindMismatch, f, t)
+			}
+			rv.SetFloat(f)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 || rv.OverflowInt(int64(f)) {
+				return fmt.Errorf("%s: %w: %v is not representable as %s", name, ErrKindMismatch, f, t)
+			}
+			rv.SetInt(int64(f))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 || rv.OverflowUint(uint64(f)) {
+				return fmt.Errorf("%s: %w: %v is not representable as %s", name, ErrKindMismatch, f, t)
+			}
+			rv.SetUint(uint64(f))
+		default:
+			return fmt.Errorf("%s: %w: %s", name, ErrUnsupportedType, t)
+		}
+	}
+	return nil
+}
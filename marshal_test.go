@@ -0,0 +1,159 @@
+package senml
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testSensor struct {
+	Temperature float64 `senml:"temperature,unit=Cel"`
+	Humidity    *int    `senml:"humidity,unit=%RH"`
+}
+
+type testDevice struct {
+	Label    string        `senml:"label"`
+	Open     bool          `senml:"open"`
+	Firmware []byte        `senml:"fw"`
+	Seen     time.Time     `senml:"seen"`
+	Uptime   time.Duration `senml:"uptime"`
+	Sensors  []testSensor  `senml:"sensors"`
+	Levels   [2]uint8      `senml:"levels,unit=%EL"`
+	Location struct {
+		Lat float64 `senml:"lat,unit=lat"`
+		Lon float64 `senml:"lon,unit=lon"`
+	} `senml:"location"`
+	Skipped  string `senml:"-"`
+	Untagged int
+	internal int
+}
+
+func TestMarshal(t *testing.T) {
+	humidity := 60
+	d := testDevice{
+		Label:    "machine room",
+		Open:     true,
+		Firmware: []byte("hi \n"),
+		Seen:     time.Unix(1320078429, 500000000),
+		Uptime:   90 * time.Minute,
+		Sensors:  []testSensor{{Temperature: 23.1}, {Temperature: 25, Humidity: &humidity}},
+		Levels:   [2]uint8{80, 45},
+		Skipped:  "skipped",
+		Untagged: 7,
+		internal: 1,
+	}
+	d.Location.Lat, d.Location.Lon = 52.5, 13.4
+
+	p, err := Marshal(&d, SetBaseName("urn:dev:ow:10e2073a01080063:"), SetBaseTime(time.Unix(1320078430, 0)))
+	if err != nil {
+		t.Fatalf("Error marshalling: %s", err)
+	}
+	expected := `[{"bn":"urn:dev:ow:10e2073a01080063:","bt":1320078430,"n":"label","vs":"machine room"},` +
+		`{"n":"open","vb":true},{"n":"fw","vd":"aGkgCg"},{"n":"seen","u":"s","v":1320078429.5},{"n":"uptime","u":"s","v":5400},` +
+		`{"n":"sensors/0/temperature","u":"Cel","v":23.1},{"n":"sensors/1/temperature","u":"Cel","v":25},` +
+		`{"n":"sensors/1/humidity","u":"%RH","v":60},{"n":"levels/0","u":"%EL","v":80},{"n":"levels/1","u":"%EL","v":45},` +
+		`{"n":"location/lat","u":"lat","v":52.5},{"n":"location/lon","u":"lon","v":13.4},{"n":"Untagged","v":7}]`
+	if stringifyPack(p) != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, stringifyPack(p))
+	}
+
+	var decoded testDevice
+	if err := Unmarshal(p, &decoded); err != nil {
+		t.Fatalf("Error unmarshalling: %s", err)
+	}
+	d.Skipped, d.internal = "", 0
+	if !decoded.Seen.Equal(d.Seen) {
+		t.Fatalf("Expected time %s. Got %s", d.Seen, decoded.Seen)
+	}
+	decoded.Seen = d.Seen
+	if !reflect.DeepEqual(decoded, d) {
+		t.Fatalf("Expected:\n%+v\nGot:\n%+v", d, decoded)
+	}
+
+	t.Run("unsupported types", func(t *testing.T) {
+		for _, v := range []interface{}{42, (*testDevice)(nil), time.Now(), struct{ M map[string]int }{M: map[string]int{}}} {
+			if _, err := Marshal(v); !errors.Is(err, ErrUnsupportedType) {
+				t.Fatalf("Expected unsupported type error for %T. Got: %v", v, err)
+			}
+		}
+		if err := Unmarshal(p, d); !errors.Is(err, ErrUnsupportedType) {
+			t.Fatalf("Expected unsupported type error. Got: %v", err)
+		}
+	})
+
+	t.Run("empty values", func(t *testing.T) {
+		p, err := Marshal(struct {
+			Label string
+			Data  []byte
+			Temp  float64
+		}{Data: []byte{}, Temp: 1})
+		if err != nil {
+			t.Fatalf("Error marshalling: %s", err)
+		}
+		if expected := `[{"n":"Temp","v":1}]`; stringifyPack(p) != expected {
+			t.Fatalf("Expected:\n%s\nGot:\n%s", expected, stringifyPack(p))
+		}
+	})
+
+	t.Run("invalid name", func(t *testing.T) {
+		_, err := Marshal(struct {
+			V float64 `senml:"-v"`
+		}{})
+		if !errors.Is(err, ErrInvalidName) {
+			t.Fatalf("Expected invalid name error. Got: %v", err)
+		}
+	})
+}
+
+func TestUnmarshal(t *testing.T) {
+	float := func(f float64) *float64 { return &f }
+
+	t.Run("unit conversion", func(t *testing.T) {
+		var s testSensor
+		err := Unmarshal(Pack{{Name: "temperature", Unit: UnitCustomDegreesFahrenheit, Value: float(212)}}, &s)
+		if err != nil || s.Temperature != 100 {
+			t.Fatalf("Unexpected temperature %v: %v", s.Temperature, err)
+		}
+		err = Unmarshal(Pack{{Name: "temperature", Unit: UnitMeter, Value: float(1)}}, &s)
+		if !errors.Is(err, ErrIncompatibleUnits) {
+			t.Fatalf("Expected incompatible units error. Got: %v", err)
+		}
+	})
+
+	t.Run("last record wins", func(t *testing.T) {
+		var s testSensor
+		p := Pack{{BaseName: "dev/", Name: "temperature", Value: float(1)}, {Name: "temperature", Value: float(2)}, {Name: "other", Value: float(3)}}
+		if err := Unmarshal(p, &s); err != nil || s.Temperature != 2 || s.Humidity != nil {
+			t.Fatalf("Unexpected sensor %+v: %v", s, err)
+		}
+	})
+
+	t.Run("index out of range", func(t *testing.T) {
+		var s struct{ L []float64 }
+		err := Unmarshal(Pack{{Name: "L/200000000", Value: float(1)}}, &s)
+		if !errors.Is(err, ErrIndexOutOfRange) || s.L != nil {
+			t.Fatalf("Expected index out of range error. Got: %v", err)
+		}
+		if err := Unmarshal(Pack{{Name: "L/1", Value: float(2)}, {Name: "L/0", Value: float(1)}}, &s); err != nil || len(s.L) != 2 {
+			t.Fatalf("Unexpected slice %v: %v", s.L, err)
+		}
+	})
+
+	t.Run("kind mismatch", func(t *testing.T) {
+		tests := []Pack{
+			{{Name: "label", Value: float(1)}},
+			{{Name: "open", StringValue: "yes"}},
+			{{Name: "fw", StringValue: "aGkgCg"}},
+			{{Name: "levels/0", Value: float(256)}},
+			{{Name: "levels/0", Value: float(1.5)}},
+			{{Name: "Untagged", BoolValue: new(bool)}},
+		}
+		for _, p := range tests {
+			var d testDevice
+			if err := Unmarshal(p, &d); !errors.Is(err, ErrKindMismatch) {
+				t.Fatalf("Expected kind mismatch error for %s. Got: %v", stringifyPack(p), err)
+			}
+		}
+	})
+}
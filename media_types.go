@@ -10,7 +10,8 @@ const (
 	MediaTypeSenmlXML  = "application/senml+xml"
 	MediaTypeSenmlEXI  = "application/senml-exi"
 	// Custom types
-	MediaTypeCustomSenmlCSV = "text/vnd.senml.v2+csv"
+	MediaTypeCustomSenmlCSV      = "text/vnd.senml.v2+csv"
+	MediaTypeCustomSenmlProtobuf = "application/vnd.senml.v2+protobuf"
 )
 
 // Sensor Streaming Measurement Lists (SenSML) Media Types
@@ -20,5 +21,29 @@ const (
 	MediaTypeSensmlXML  = "application/sensml+xml"
 	MediaTypeSensmlEXI  = "application/sensml-exi"
 	// Custom types
-	MediaTypeCustomSensmlCSV = "text/vnd.sensml.v2+csv"
+	MediaTypeCustomSensmlCSV      = "text/vnd.sensml.v2+csv"
+	MediaTypeCustomSensmlProtobuf = "application/vnd.sensml.v2+protobuf"
+)
+
+// SenML FETCH/PATCH Media Types
+// https://tools.ietf.org/html/rfc8790#section-9
+const (
+	MediaTypeSenmlEtchJSON = "application/senml-etch+json"
+	MediaTypeSenmlEtchCBOR = "application/senml-etch+cbor"
+)
+
+// CoAP Content-Format numbers of the SenML and SenSML Media Types
+// https://tools.ietf.org/html/rfc8428#section-12.3
+const (
+	ContentFormatSenmlJSON  = 110
+	ContentFormatSensmlJSON = 111
+	ContentFormatSenmlCBOR  = 112
+	ContentFormatSensmlCBOR = 113
+	ContentFormatSenmlEXI   = 114
+	ContentFormatSensmlEXI  = 115
+	ContentFormatSenmlXML   = 310
+	ContentFormatSensmlXML  = 311
+	// https://tools.ietf.org/html/rfc8790#section-9
+	ContentFormatSenmlEtchJSON = 320
+	ContentFormatSenmlEtchCBOR = 322
 )
@@ -0,0 +1,142 @@
+package senml
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// ErrDuplicateRecord is returned by Dedup with the DedupError policy, usable with errors.Is
+var ErrDuplicateRecord = errors.New("conflicting records with the same name and time")
+
+// DedupPolicy decides which of the records with the same resolved name and time is kept by Dedup
+type DedupPolicy int
+
+const (
+	// DedupFirstWins keeps the first of the duplicate records
+	DedupFirstWins DedupPolicy = iota
+	// DedupLastWins keeps the last of the duplicate records, at the position of the first
+	DedupLastWins
+	// DedupError returns an error for duplicate records which are not equal
+	DedupError
+)
+
+// recordKey identifies a resolved record
+type recordKey struct {
+	name string
+	time float64
+}
+
+func keyOf(r Record) recordKey {
+	return recordKey{name: r.Name, time: r.Time}
+}
+
+// equalRecords reports whether the resolved records have the same fields and extensions
+func equalRecords(a, b Record) bool {
+	a.XMLName, b.XMLName = nil, nil
+	if len(a.Extensions) == 0 && len(b.Extensions) == 0 {
+		a.Extensions, b.Extensions = nil, nil
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// Dedup returns a resolved copy of the pack with a single record for each resolved name and time, chosen by the policy.
+// Records keep the order of their first occurrence. The options are those of Pack.Normalize, e.g. SetClock.
+// With the DedupError policy, equal duplicates are removed and the error is a ValidationError for the first conflicting record.
+func (p Pack) Dedup(policy DedupPolicy, options ...NormalizeOption) (Pack, error) {
+	return p.Normalized(options...).dedup(policy)
+}
+
+// dedup deduplicates the resolved pack in place
+func (p Pack) dedup(policy DedupPolicy) (Pack, error) {
+	index := make(map[recordKey]int, len(p))
+	result := p[:0]
+	for i, r := range p {
+		first, found := index[keyOf(r)]
+		if !found {
+			index[keyOf(r)] = len(result)
+			result = append(result, r)
+			continue
+		}
+		switch policy {
+		case DedupLastWins:
+			result[first] = r
+		case DedupError:
+			if !equalRecords(result[first], r) {
+				return nil, &ValidationError{Index: i, Field: "n", Err: ErrDuplicateRecord}
+			}
+		}
+	}
+	return result, nil
+}
+
+// SameTime returns the normalization options with the current time of their clock fixed,
+// so that multiple packs, or the records of a stream, are resolved using the same current time
+func SameTime(options ...NormalizeOption) []NormalizeOption {
+	o := &normalizeOptions{clock: time.Now}
+	for _, opt := range options {
+		opt(o)
+	}
+	now := o.clock()
+	return append(options[:len(options):len(options)], SetClock(func() time.Time { return now }))
+}
+
+// Merge returns a resolved pack with the records of all packs, sorted by time.
+// Records of later packs replace those of earlier packs with the same resolved name and time.
+// Relative times of all packs are resolved using the same current time; see MergeWith for other options.
+func Merge(packs ...Pack) Pack {
+	return MergeWith(nil, packs...)
+}
+
+// MergeWith is Merge with the options of Pack.Normalize, e.g. SetClock or SetKeepRelativeTime.
+// Relative times of all packs are resolved using the same current time, unless kept relative.
+func MergeWith(options []NormalizeOption, packs ...Pack) Pack {
+	options = SameTime(options...)
+	var merged Pack
+	for _, p := range packs {
+		merged = append(merged, p.Normalized(options...)...)
+	}
+	merged, _ = merged.dedup(DedupLastWins)
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Time < merged[j].Time })
+	return merged
+}
+
+// Diff returns the resolved records of the updated pack which are not in the old pack or have changed,
+// followed by deletion records, i.e. with only a name and time, for the records of the old pack which are not in the updated one.
+// Records are identified by their resolved name and time. The result is a PATCH pack for the old pack; see ApplyPatch.
+// Duplicate records are deduplicated with DedupLastWins.
+//
+// The options are those of Pack.Normalize. Relative times are kept relative as by ApplyPatch,
+// unless a clock is set with SetClock, in which case those of both packs are resolved using the same current time.
+func Diff(old, updated Pack, options ...NormalizeOption) Pack {
+	o := &normalizeOptions{}
+	for _, opt := range options {
+		opt(o)
+	}
+	if o.clock == nil {
+		options = append(options[:len(options):len(options)], SetKeepRelativeTime)
+	}
+	options = SameTime(options...)
+	resolvedOld, _ := old.Normalized(options...).dedup(DedupLastWins)
+	resolvedNew, _ := updated.Normalized(options...).dedup(DedupLastWins)
+
+	oldRecords := make(map[recordKey]Record, len(resolvedOld))
+	for _, r := range resolvedOld {
+		oldRecords[keyOf(r)] = r
+	}
+	var diff Pack
+	newKeys := make(map[recordKey]bool, len(resolvedNew))
+	for _, r := range resolvedNew {
+		newKeys[keyOf(r)] = true
+		if o, found := oldRecords[keyOf(r)]; !found || !equalRecords(o, r) {
+			diff = append(diff, r)
+		}
+	}
+	for _, r := range resolvedOld {
+		if !newKeys[keyOf(r)] {
+			diff = append(diff, Record{Name: r.Name, Time: r.Time})
+		}
+	}
+	return diff
+}
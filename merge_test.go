@@ -0,0 +1,165 @@
+package senml
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDedup(t *testing.T) {
+	float := func(f float64) *float64 { return &f }
+	p := Pack{
+		{BaseName: "dev/", BaseTime: 1320078429, Name: "temp", Value: float(23.1)},
+		{Name: "humidity", Value: float(60)},
+		{Name: "temp", Value: float(23.1)},
+		{Name: "temp", Value: float(23.5), Time: 1},
+		{Name: "temp", Value: float(24)},
+	}
+
+	t.Run("first wins", func(t *testing.T) {
+		result, err := p.Dedup(DedupFirstWins)
+		if err != nil {
+			t.Fatalf("Error deduplicating: %s", err)
+		}
+		if len(result) != 3 || *result[0].Value != 23.1 || result[2].Time != 1320078430 {
+			t.Fatalf("Unexpected records: %s", stringifyPack(result))
+		}
+	})
+
+	t.Run("last wins", func(t *testing.T) {
+		result, err := p.Dedup(DedupLastWins)
+		if err != nil {
+			t.Fatalf("Error deduplicating: %s", err)
+		}
+		if len(result) != 3 || result[0].Name != "dev/temp" || *result[0].Value != 24 {
+			t.Fatalf("Unexpected records: %s", stringifyPack(result))
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := p[:4].Dedup(DedupError)
+		if err != nil {
+			t.Fatalf("Error for equal duplicates: %s", err)
+		}
+		_, err = p.Dedup(DedupError)
+		var verr *ValidationError
+		if !errors.As(err, &verr) || verr.Index != 4 || !errors.Is(err, ErrDuplicateRecord) {
+			t.Fatalf("Expected duplicate record error for record 4. Got: %v", err)
+		}
+	})
+
+	if p[0].BaseName != "dev/" || *p[4].Value != 24 {
+		t.Fatalf("Pack was modified: %s", stringifyPack(p))
+	}
+}
+
+func TestMerge(t *testing.T) {
+	float := func(f float64) *float64 { return &f }
+	a := Pack{
+		{BaseName: "dev/", Name: "temp", Value: float(23.1), Time: 1320078430},
+		{Name: "humidity", Value: float(60), Time: 1320078429},
+	}
+	b := Pack{
+		{Name: "dev/temp", Value: float(23.2), Time: 1320078430},
+		{Name: "dev/temp", Value: float(22.9), Time: 1320078428},
+	}
+
+	merged := Merge(a, b)
+	if len(merged) != 3 {
+		t.Fatalf("Unexpected records: %s", stringifyPack(merged))
+	}
+	for i, expected := range []float64{22.9, 60, 23.2} {
+		if *merged[i].Value != expected {
+			t.Fatalf("Expected %v for record %d. Got: %s", expected, i, stringifyPack(merged))
+		}
+	}
+	if merged[1].Name != "dev/humidity" {
+		t.Fatalf("Records were not resolved: %s", stringifyPack(merged))
+	}
+
+	t.Run("options", func(t *testing.T) {
+		relative := Pack{{Name: "dev/temp", Value: float(23), Time: -10}}
+		now := time.Unix(1320078440, 0)
+		merged := MergeWith([]NormalizeOption{SetClock(func() time.Time { return now })}, a, relative)
+		if len(merged) != 2 || merged[1].Time != 1320078430 || *merged[1].Value != 23 {
+			t.Fatalf("Relative times were not resolved with the clock: %s", stringifyPack(merged))
+		}
+		merged = MergeWith([]NormalizeOption{SetKeepRelativeTime}, a, relative)
+		if len(merged) != 3 || merged[0].Time != -10 {
+			t.Fatalf("Relative times were not kept: %s", stringifyPack(merged))
+		}
+	})
+}
+
+func TestDiff(t *testing.T) {
+	float := func(f float64) *float64 { return &f }
+	old := Pack{
+		{BaseName: "dev/", BaseTime: 1320078429, Name: "temp", Unit: UnitCelsius, Value: float(23.1)},
+		{Name: "humidity", Unit: UnitRelativeHumidity, Value: float(60)},
+		{Name: "status", StringValue: "ok"},
+	}
+	updated := Pack{
+		{Name: "dev/temp", Unit: UnitCelsius, Value: float(23.1), Time: 1320078429},
+		{Name: "dev/humidity", Unit: UnitRelativeHumidity, Value: float(61), Time: 1320078429},
+		{Name: "dev/temp", Unit: UnitCelsius, Value: float(23.4), Time: 1320078430},
+	}
+
+	diff := Diff(old, updated)
+	if len(diff) != 3 {
+		t.Fatalf("Unexpected records: %s", stringifyPack(diff))
+	}
+	if *diff[0].Value != 61 || *diff[1].Value != 23.4 {
+		t.Fatalf("Unexpected changed records: %s", stringifyPack(diff))
+	}
+	if diff[2].Name != "dev/status" || diff[2].Kind() != KindNone || diff[2].Time != 1320078429 {
+		t.Fatalf("Unexpected deletion record: %s", stringifyPack(diff))
+	}
+
+	patched, err := ApplyPatch(old, diff)
+	if err != nil {
+		t.Fatalf("Error patching: %s", err)
+	}
+	if len(Diff(patched, updated)) != 0 {
+		t.Fatalf("Patched pack differs from updated pack: %s", stringifyPack(patched))
+	}
+
+	if len(Diff(updated, updated)) != 0 {
+		t.Fatalf("Diff of equal packs is not empty")
+	}
+
+	t.Run("relative times", func(t *testing.T) {
+		old := Pack{
+			{BaseName: "dev/", Name: "temp", Value: float(23.1), Time: -10},
+			{Name: "temp", Value: float(23.2), Time: -5},
+			{Name: "humidity", Value: float(60), Time: -5},
+		}
+		updated := Pack{
+			{BaseName: "dev/", Name: "temp", Value: float(23.4), Time: -5},
+			{Name: "humidity", Value: float(60), Time: -5},
+			{Name: "co2", Value: float(400), Time: -1},
+		}
+
+		diff := Diff(old, updated)
+		for _, r := range diff {
+			if r.Time >= 0 {
+				t.Fatalf("Relative times were not kept: %s", stringifyPack(diff))
+			}
+		}
+		patched, err := ApplyPatch(old, diff)
+		if err != nil {
+			t.Fatalf("Error patching: %s", err)
+		}
+		if len(Diff(patched, updated)) != 0 {
+			t.Fatalf("Patched pack differs from updated pack: %s", stringifyPack(patched))
+		}
+		if len(patched) != 3 {
+			t.Fatalf("Unexpected records: %s", stringifyPack(patched))
+		}
+
+		now := time.Unix(1320078430, 0)
+		resolved := Diff(old, updated, SetClock(func() time.Time { return now }))
+		if len(resolved) != len(diff) || resolved[0].Time != 1320078425 {
+			t.Fatalf("Relative times were not resolved: %s", stringifyPack(resolved))
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package senml
+
+import (
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Filter reports whether a resolved record is selected; see Pack.Select
+type Filter func(r Record) bool
+
+// Select returns the resolved records of the pack which match all of the filters.
+// The pack may be raw or resolved; it is not modified. Relative times are resolved using the current time;
+// see SelectWith for other options.
+func (p Pack) Select(filters ...Filter) Pack {
+	return p.SelectWith(nil, filters...)
+}
+
+// SelectWith is Select with the options of Pack.Normalize, e.g. SetClock or SetKeepRelativeTime
+func (p Pack) SelectWith(options []NormalizeOption, filters ...Filter) Pack {
+	var selected Pack
+	for _, r := range p.Normalized(options...) {
+		if matchesAll(r, filters) {
+			selected = append(selected, r)
+		}
+	}
+	return selected
+}
+
+func matchesAll(r Record, filters []Filter) bool {
+	for _, f := range filters {
+		if !f(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// NameEquals selects the records with the given resolved name
+func NameEquals(name string) Filter {
+	return func(r Record) bool {
+		return r.Name == name
+	}
+}
+
+// NamePrefix selects the records whose resolved name begins with the prefix
+func NamePrefix(prefix string) Filter {
+	return func(r Record) bool {
+		return strings.HasPrefix(r.Name, prefix)
+	}
+}
+
+// NameGlob selects the records whose resolved name matches the shell pattern, with the syntax of path.Match.
+// As in paths, * and ? do not match the / separator. A malformed pattern selects no records.
+func NameGlob(pattern string) Filter {
+	return func(r Record) bool {
+		matched, err := path.Match(pattern, r.Name)
+		return err == nil && matched
+	}
+}
+
+// NameRegexp selects the records whose resolved name matches the regular expression
+func NameRegexp(re *regexp.Regexp) Filter {
+	return func(r Record) bool {
+		return re.MatchString(r.Name)
+	}
+}
+
+// Between selects the records with a time in the range [from, to).
+// A zero from or to leaves the range unbounded on that side.
+// Relative times are compared as resolved by Select, or by SelectWith with the given clock.
+func Between(from, to time.Time) Filter {
+	return func(r Record) bool {
+		sec, nsec := splitSeconds(r.Time)
+		t := time.Unix(sec, nsec)
+		return (from.IsZero() || !t.Before(from)) && (to.IsZero() || t.Before(to))
+	}
+}
+
+// HasUnit selects the records with one of the given units
+func HasUnit(units ...string) Filter {
+	return func(r Record) bool {
+		for _, u := range units {
+			if r.Unit == u {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// HasKind selects the records with one of the given value kinds
+func HasKind(kinds ...ValueKind) Filter {
+	return func(r Record) bool {
+		kind := r.Kind()
+		for _, k := range kinds {
+			if kind == k {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ValueMatches selects the records with a float value for which the predicate returns true
+func ValueMatches(predicate func(v float64) bool) Filter {
+	return func(r Record) bool {
+		v, ok := r.Float()
+		return ok && predicate(v)
+	}
+}
+
+// Not selects the records which are not selected by the filter
+func Not(f Filter) Filter {
+	return func(r Record) bool {
+		return !f(r)
+	}
+}
+
+// AnyOf selects the records which are selected by at least one of the filters
+func AnyOf(filters ...Filter) Filter {
+	return func(r Record) bool {
+		for _, f := range filters {
+			if f(r) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,78 @@
+package senml
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestSelect(t *testing.T) {
+	float := func(f float64) *float64 { return &f }
+	p := Pack{
+		{BaseName: "urn:dev:ow:10e2073a01080063:", BaseTime: 1320078429, Name: "temp", Unit: UnitCelsius, Value: float(23.1)},
+		{Name: "humidity", Unit: UnitRelativeHumidity, Value: float(60), Time: 1},
+		{BaseName: "urn:dev:ow:10e2073a0108006:", Name: "temp", Unit: UnitCelsius, Value: float(25), Time: 2},
+		{BaseName: "building/floor1/", Name: "room1/status", StringValue: "ok", Time: 3},
+		{Name: "room2/temp", Unit: UnitCelsius, Value: float(21), Time: 4},
+	}
+	at := func(s int64) time.Time { return time.Unix(s, 0) }
+
+	tests := []struct {
+		name     string
+		filters  []Filter
+		expected []string
+	}{
+		{"all", nil, []string{
+			"urn:dev:ow:10e2073a01080063:temp", "urn:dev:ow:10e2073a01080063:humidity", "urn:dev:ow:10e2073a0108006:temp",
+			"building/floor1/room1/status", "building/floor1/room2/temp"}},
+		{"equals", []Filter{NameEquals("urn:dev:ow:10e2073a0108006:temp")}, []string{"urn:dev:ow:10e2073a0108006:temp"}},
+		{"prefix", []Filter{NamePrefix("urn:dev:ow:10e2073a01080063:")}, []string{
+			"urn:dev:ow:10e2073a01080063:temp", "urn:dev:ow:10e2073a01080063:humidity"}},
+		{"glob", []Filter{NameGlob("building/floor1/*/temp")}, []string{"building/floor1/room2/temp"}},
+		{"malformed glob", []Filter{NameGlob("[")}, nil},
+		{"regexp", []Filter{NameRegexp(regexp.MustCompile(`:temp$`))}, []string{
+			"urn:dev:ow:10e2073a01080063:temp", "urn:dev:ow:10e2073a0108006:temp"}},
+		{"between", []Filter{Between(at(1320078430), at(1320078432))}, []string{
+			"urn:dev:ow:10e2073a01080063:humidity", "urn:dev:ow:10e2073a0108006:temp"}},
+		{"since", []Filter{Between(at(1320078432), time.Time{})}, []string{
+			"building/floor1/room1/status", "building/floor1/room2/temp"}},
+		{"unit", []Filter{HasUnit(UnitRelativeHumidity, UnitKelvin)}, []string{"urn:dev:ow:10e2073a01080063:humidity"}},
+		{"kind", []Filter{HasKind(KindString)}, []string{"building/floor1/room1/status"}},
+		{"value", []Filter{HasUnit(UnitCelsius), ValueMatches(func(v float64) bool { return v > 22 })}, []string{
+			"urn:dev:ow:10e2073a01080063:temp", "urn:dev:ow:10e2073a0108006:temp"}},
+		{"not", []Filter{Not(NamePrefix("urn:"))}, []string{"building/floor1/room1/status", "building/floor1/room2/temp"}},
+		{"any of", []Filter{AnyOf(HasKind(KindString), NameEquals("urn:dev:ow:10e2073a0108006:temp"))}, []string{
+			"urn:dev:ow:10e2073a0108006:temp", "building/floor1/room1/status"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selected := p.Select(test.filters...)
+			if len(selected) != len(test.expected) {
+				t.Fatalf("Expected %d records. Got: %s", len(test.expected), stringifyPack(selected))
+			}
+			for i, name := range test.expected {
+				if selected[i].Name != name {
+					t.Fatalf("Expected %s for record %d. Got: %s", name, i, stringifyPack(selected))
+				}
+			}
+		})
+	}
+
+	if p[1].Name != "humidity" || p[1].Time != 1 {
+		t.Fatalf("Pack was modified: %s", stringifyPack(p))
+	}
+
+	t.Run("options", func(t *testing.T) {
+		relative := Pack{{Name: "a", Value: float(1), Time: -10}, {Name: "b", Value: float(2)}}
+		clock := SetClock(func() time.Time { return at(1320078430) })
+		selected := relative.SelectWith([]NormalizeOption{clock}, Between(at(1320078425), time.Time{}))
+		if len(selected) != 1 || selected[0].Name != "b" || selected[0].Time != 1320078430 {
+			t.Fatalf("Unexpected records: %s", stringifyPack(selected))
+		}
+		selected = relative.SelectWith([]NormalizeOption{SetKeepRelativeTime}, NameEquals("a"))
+		if len(selected) != 1 || selected[0].Time != -10 {
+			t.Fatalf("Unexpected records: %s", stringifyPack(selected))
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package senml
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+// Examples based on https://tools.ietf.org/html/rfc8428#section-5.1
+var rfc8428Examples = []struct {
+	section string
+	pack    string
+}{
+	{"5.1.1 single datapoint", `[{"n":"urn:dev:ow:10e2073a01080063","u":"Cel","v":23.1}]`},
+	{"5.1.2 multiple datapoints", `[
+		{"bn":"urn:dev:ow:10e2073a01080063:","n":"voltage","u":"V","v":120.1},
+		{"n":"current","u":"A","v":1.2}]`},
+	{"5.1.3 multiple measurements", `[
+		{"bn":"urn:dev:ow:10e2073a0108006:","bt":1.276020076001e+09,"bu":"A","bver":5,"n":"voltage","u":"V","v":120.1},
+		{"n":"current","t":-5,"v":1.2},
+		{"n":"current","t":-4,"v":1.3},
+		{"n":"current","t":-3,"v":1.4},
+		{"n":"current","t":-2,"v":1.5},
+		{"n":"current","t":-1,"v":1.6},
+		{"n":"current","v":1.7}]`},
+	{"5.1.3 multiple measurements, normalized", `[
+		{"bver":5,"n":"urn:dev:ow:10e2073a0108006:voltage","u":"V","t":1.276020076001e+09,"v":120.1},
+		{"bver":5,"n":"urn:dev:ow:10e2073a0108006:current","u":"A","t":1.276020071001e+09,"v":1.2},
+		{"bver":5,"n":"urn:dev:ow:10e2073a0108006:current","u":"A","t":1.276020072001e+09,"v":1.3},
+		{"bver":5,"n":"urn:dev:ow:10e2073a0108006:current","u":"A","t":1.276020073001e+09,"v":1.4},
+		{"bver":5,"n":"urn:dev:ow:10e2073a0108006:current","u":"A","t":1.276020074001e+09,"v":1.5},
+		{"bver":5,"n":"urn:dev:ow:10e2073a0108006:current","u":"A","t":1.276020075001e+09,"v":1.6},
+		{"bver":5,"n":"urn:dev:ow:10e2073a0108006:current","u":"A","t":1.276020076001e+09,"v":1.7}]`},
+	{"5.1.4 resolved data", `[
+		{"n":"urn:dev:ow:10e2073a0108006:voltage","u":"V","t":1.276020076001e+09,"v":120.1},
+		{"n":"urn:dev:ow:10e2073a0108006:current","u":"A","t":1.276020071001e+09,"v":1.2}]`},
+	{"5.1.5 multiple data types", `[
+		{"bn":"urn:dev:ow:10e2073a01080063:","bt":1.320067464e+09,"bu":"%RH","v":20},
+		{"u":"lon","v":24.30621},
+		{"u":"lat","v":60.07965},
+		{"t":60,"v":20.3},
+		{"u":"lon","t":60,"v":24.30622},
+		{"u":"lat","t":60,"v":60.07965},
+		{"t":120,"v":20.7}]`},
+	{"5.1.6 collection of resources", `[
+		{"bn":"urn:dev:ow:10e2073a01080063:","n":"temp","u":"Cel","v":23.1},
+		{"n":"label","vs":"Machine Room"},
+		{"n":"open","vb":false},
+		{"n":"nfc-reader","vd":"aGkgCg"}]`},
+}
+
+func TestRFC8428Examples(t *testing.T) {
+	for _, example := range rfc8428Examples {
+		t.Run(example.section, func(t *testing.T) {
+			var p Pack
+			err := json.Unmarshal([]byte(example.pack), &p)
+			if err != nil {
+				t.Fatalf("Error decoding example: %s", err)
+			}
+			err = p.ValidateAll()
+			if err != nil {
+				t.Fatalf("Error for valid example: %s", err)
+			}
+			err = p.Normalized().ValidateAll()
+			if err != nil {
+				t.Fatalf("Error for normalized example: %s", err)
+			}
+		})
+	}
+}
+
+func TestRFC8428Violations(t *testing.T) {
+	float := func(f float64) *float64 { return &f }
+	integer := func(i int) *int { return &i }
+	valid := func(r Record) Record {
+		if r.Name == "" {
+			r.Name = "dev"
+		}
+		if r.Value == nil && r.StringValue == "" && r.DataValue == "" && r.BoolValue == nil && r.Sum == nil {
+			r.Value = float(1)
+		}
+		return r
+	}
+
+	tests := []struct {
+		name  string
+		pack  Pack
+		field string
+		err   error
+	}{
+		{"zero base version", Pack{valid(Record{BaseVersion: integer(0)})}, "bver", ErrInvalidVersion},
+		{"negative base version", Pack{valid(Record{BaseVersion: integer(-1)})}, "bver", ErrInvalidVersion},
+		{"unsupported base version", Pack{valid(Record{BaseVersion: integer(DefaultBaseVersion + 1)})}, "bver", ErrUnsupportedVersion},
+		{"version change", Pack{valid(Record{}), valid(Record{BaseVersion: integer(5)})}, "bver", ErrVersionChange},
+		{"repeated version change", Pack{valid(Record{BaseVersion: integer(5)}), valid(Record{BaseVersion: integer(5)}), valid(Record{BaseVersion: integer(4)})}, "bver", ErrVersionChange},
+		{"empty name", Pack{{Value: float(1)}}, "n", ErrEmptyName},
+		{"invalid name", Pack{valid(Record{Name: "dev#1"})}, "n", ErrInvalidName},
+		{"invalid resolved name", Pack{valid(Record{BaseName: "_dev", Name: "1"})}, "n", ErrInvalidName},
+		{"NaN base time", Pack{valid(Record{BaseTime: math.NaN()})}, "bt", ErrNotFinite},
+		{"infinite base value", Pack{valid(Record{BaseValue: float(math.Inf(1))})}, "bv", ErrNotFinite},
+		{"infinite base sum", Pack{valid(Record{BaseSum: float(math.Inf(-1))})}, "bs", ErrNotFinite},
+		{"infinite time", Pack{valid(Record{Time: math.Inf(1)})}, "t", ErrNotFinite},
+		{"NaN update time", Pack{valid(Record{UpdateTime: math.NaN()})}, "ut", ErrNotFinite},
+		{"NaN value", Pack{valid(Record{Value: float(math.NaN())})}, "v", ErrNotFinite},
+		{"infinite sum", Pack{valid(Record{Sum: float(math.Inf(1))})}, "s", ErrNotFinite},
+		{"invalid UTF-8 base unit", Pack{valid(Record{BaseUnit: "\xff"})}, "bu", ErrInvalidUTF8},
+		{"invalid UTF-8 unit", Pack{valid(Record{Unit: "\xff"})}, "u", ErrInvalidUTF8},
+		{"invalid UTF-8 string value", Pack{valid(Record{StringValue: "\xff"})}, "vs", ErrInvalidUTF8},
+		{"padded data value", Pack{valid(Record{DataValue: "aGkgCg=="})}, "vd", ErrInvalidDataValue},
+		{"standard base64 data value", Pack{valid(Record{DataValue: "+/+/"})}, "vd", ErrInvalidDataValue},
+		{"float and string values", Pack{valid(Record{Value: float(1), StringValue: "on"})}, "v", ErrTooManyValues},
+		{"base value and data value", Pack{valid(Record{BaseValue: float(1), DataValue: "aGkgCg"})}, "v", ErrTooManyValues},
+		{"sum and boolean value", Pack{valid(Record{BoolValue: new(bool), Sum: float(1)})}, "s", ErrSumWithNonFloat},
+		{"no value", Pack{{Name: "dev"}}, "v", ErrNoValue},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.pack.Validate()
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Expected validation error. Got: %v in pack: %s", err, stringifyPack(test.pack))
+			}
+			if verr.Field != test.field || !errors.Is(err, test.err) {
+				t.Fatalf("Expected error for field %s: %s. Got: %s", test.field, test.err, err)
+			}
+		})
+	}
+}
@@ -6,15 +6,21 @@
 package senml
 
 import (
+	"encoding/base64"
 	"fmt"
+	"math"
 	"regexp"
 	"time"
+	"unicode/utf8"
 )
 
 // DefaultBaseVersion is the default version of the SenML data model based on:
 // https://tools.ietf.org/html/rfc8428
 const DefaultBaseVersion = 10
 
+// rfc8428: values less than 2**28 represent time relative to the current time.
+const pivot = 268435456
+
 // Pack is a SenML Pack which is one or more SenML Records in an array structure.
 type Pack []Record
 
@@ -54,13 +60,46 @@ type Record struct {
 	BoolValue *bool `json:"vb,omitempty"  xml:"vb,attr,omitempty" cbor:"4,keyasint,omitempty"`
 	// Sum is the integrated sum of the float values over time.
 	Sum *float64 `json:"s,omitempty"  xml:"s,attr,omitempty" cbor:"5,keyasint,omitempty"`
+
+	// Extensions are the fields not defined in RFC8428, keyed by their label.
+	// Labels ending in an underscore must be understood by the application; see RegisterMustUnderstand.
+	// Extensions are encoded by the JSON, CBOR and XML codecs.
+	Extensions map[string]interface{} `json:"-" xml:"-" cbor:"-"`
+}
+
+type normalizeOptions struct {
+	clock            func() time.Time
+	keepRelativeTime bool
+}
+
+// NormalizeOption is the function type for setting normalization options
+type NormalizeOption func(*normalizeOptions)
+
+// SetClock sets the function returning the current time, which is used to resolve relative times. The default is time.Now.
+func SetClock(clock func() time.Time) NormalizeOption {
+	return func(o *normalizeOptions) {
+		o.clock = clock
+	}
+}
+
+// SetKeepRelativeTime keeps relative times relative to the time of normalization, instead of converting them to absolute times
+func SetKeepRelativeTime(o *normalizeOptions) {
+	o.keepRelativeTime = true
 }
 
 // Normalize converts the SenML Pack to to the resolved format according to:
 // https://tools.ietf.org/html/rfc8428#section-4.6
 //
 // Normalize must be called on a validated pack only.
-func (p Pack) Normalize() {
+func (p Pack) Normalize(options ...NormalizeOption) {
+	o := &normalizeOptions{
+		clock:            time.Now,
+		keepRelativeTime: false,
+	}
+	for _, opt := range options {
+		opt(o)
+	}
+
 	var bname string
 	var btime float64
 	var bunit string
@@ -68,8 +107,7 @@ func (p Pack) Normalize() {
 	var bvalue float64
 	var bsum float64
 
-	var now = float64(time.Now().UnixNano()) / 1000000000
-	const pivot = 268435456 // rfc8428: values less than 2**28 represent time relative to the current time.
+	var now = float64(o.clock().UnixNano()) / 1000000000
 	var r *Record
 	for i := range p {
 		r = &p[i]
@@ -80,7 +118,7 @@ func (p Pack) Normalize() {
 			r.BaseTime = 0
 		}
 		r.Time = btime + r.Time
-		if r.Time < pivot {
+		if r.Time < pivot && !o.keepRelativeTime {
 			// convert to absolute time
 			r.Time = now + r.Time
 		}
@@ -140,6 +178,13 @@ func (p Pack) Normalize() {
 	return
 }
 
+// Normalized returns a resolved copy of the SenML Pack, leaving the pack unmodified. See Normalize.
+func (p Pack) Normalized(options ...NormalizeOption) Pack {
+	clone := p.Clone()
+	clone.Normalize(options...)
+	return clone
+}
+
 // Clone returns a deep copy of the SenML Pack
 func (p Pack) Clone() (clone Pack) {
 	cloneBool := func(b *bool) *bool {
@@ -166,6 +211,16 @@ func (p Pack) Clone() (clone Pack) {
 		}
 		return nil
 	}
+	cloneExtensions := func(m map[string]interface{}) map[string]interface{} {
+		if m != nil {
+			clone := make(map[string]interface{}, len(m))
+			for k, v := range m {
+				clone[k] = v
+			}
+			return clone
+		}
+		return nil
+	}
 	clone = make(Pack, len(p))
 	for i := range p {
 		clone[i] = Record{
@@ -185,31 +240,79 @@ func (p Pack) Clone() (clone Pack) {
 			DataValue:   p[i].DataValue,
 			BoolValue:   cloneBool(p[i].BoolValue),
 			Sum:         cloneFloat64(p[i].Sum),
+			Extensions:  cloneExtensions(p[i].Extensions),
 		}
 	}
 
 	return clone
 }
 
-// Validate tests if the SenML Pack is valid
-func (p Pack) Validate() error {
+type validateOptions struct {
+	strictUnits bool
+}
+
+// ValidateOption is the function type for setting validation options
+type ValidateOption func(*validateOptions)
+
+// SetStrictUnits enables rejection of units which are not in the Units registry
+func SetStrictUnits(o *validateOptions) {
+	o.strictUnits = true
+}
+
+// Validate tests if the SenML Pack is valid according to:
+// https://tools.ietf.org/html/rfc8428#section-4
+//
+// It returns a *ValidationError for the first violation found in the pack.
+func (p Pack) Validate(options ...ValidateOption) error {
+	errs := p.validate(false, options...)
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// ValidateAll tests if the SenML Pack is valid, similar to Validate.
+// Unlike Validate, it does not stop at the first violation and returns ValidationErrors with every violation found in the pack.
+func (p Pack) ValidateAll(options ...ValidateOption) error {
+	errs := p.validate(true, options...)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validate returns the violations found in the pack. It stops at the first violation unless all is set.
+func (p Pack) validate(all bool, options ...ValidateOption) (errs ValidationErrors) {
+	o := &validateOptions{
+		strictUnits: false,
+	}
+	for _, opt := range options {
+		opt(o)
+	}
+
 	var bname string
-	var bver = -1
 
-	for _, r := range p {
+	report := func(index int, field string, err error) bool {
+		errs = append(errs, &ValidationError{Index: index, Field: field, Err: err})
+		return !all
+	}
+
+	for i, r := range p {
 		// validate version
-		if r.BaseVersion == nil {
-			if bver == -1 {
-				bver = DefaultBaseVersion
+		if r.BaseVersion != nil {
+			if *r.BaseVersion <= 0 {
+				if report(i, "bver", ErrInvalidVersion) {
+					return errs
+				}
+			} else if *r.BaseVersion > DefaultBaseVersion {
+				// rfc8428: a system must not use an object with a version larger than the one it understands
+				if report(i, "bver", ErrUnsupportedVersion) {
+					return errs
+				}
 			}
-		} else {
-			if *r.BaseVersion < 0 {
-				fmt.Errorf("negative base version")
-			}
-			if bver == -1 {
-				bver = *r.BaseVersion
-			} else {
-				return fmt.Errorf("unallowed version change")
+			// the version is set by the first record, and may only be repeated, as in resolved packs
+			if i > 0 && (p[0].BaseVersion == nil || *r.BaseVersion != *p[0].BaseVersion) && report(i, "bver", ErrVersionChange) {
+				return errs
 			}
 		}
 
@@ -219,8 +322,60 @@ func (p Pack) Validate() error {
 		}
 		name := bname + r.Name
 		err := ValidateName(name)
-		if err != nil {
-			return err
+		if err != nil && report(i, "n", err) {
+			return errs
+		}
+
+		// validate numbers
+		for _, f := range []struct {
+			label string
+			value *float64
+		}{
+			{"bt", &r.BaseTime}, {"bv", r.BaseValue}, {"bs", r.BaseSum},
+			{"t", &r.Time}, {"ut", &r.UpdateTime}, {"v", r.Value}, {"s", r.Sum},
+		} {
+			if f.value != nil && (math.IsNaN(*f.value) || math.IsInf(*f.value, 0)) {
+				if report(i, f.label, ErrNotFinite) {
+					return errs
+				}
+			}
+		}
+
+		// validate strings
+		if !utf8.ValidString(r.BaseUnit) && report(i, "bu", ErrInvalidUTF8) {
+			return errs
+		}
+		if !utf8.ValidString(r.Unit) && report(i, "u", ErrInvalidUTF8) {
+			return errs
+		}
+		if !utf8.ValidString(r.StringValue) && report(i, "vs", ErrInvalidUTF8) {
+			return errs
+		}
+
+		// validate units
+		if o.strictUnits {
+			if _, found := Units.Lookup(r.BaseUnit); r.BaseUnit != "" && !found && report(i, "bu", ErrUnknownUnit) {
+				return errs
+			}
+			if _, found := Units.Lookup(r.Unit); r.Unit != "" && !found && report(i, "u", ErrUnknownUnit) {
+				return errs
+			}
+		}
+		if _, err := base64.RawURLEncoding.DecodeString(r.DataValue); err != nil && report(i, "vd", ErrInvalidDataValue) {
+			return errs
+		}
+
+		// validate extensions
+		for _, label := range sortedLabels(r.Extensions) {
+			if conflictsWithField(label) {
+				if report(i, label, ErrExtensionConflict) {
+					return errs
+				}
+			} else if !IsUnderstood(label) {
+				if report(i, label, ErrMustUnderstand) {
+					return errs
+				}
+			}
 		}
 
 		// validate values
@@ -240,35 +395,39 @@ func (p Pack) Validate() error {
 		}
 
 		if floatValueCount+nonFloatValueCount > 1 {
-			return fmt.Errorf("too many values in single record")
-		}
-
-		if nonFloatValueCount == 1 {
+			if report(i, "v", ErrTooManyValues) {
+				return errs
+			}
+		} else if nonFloatValueCount == 1 {
 			if r.Sum != nil || r.BaseSum != nil {
-				return fmt.Errorf("sum together with non-float value in a single record")
+				if report(i, "s", ErrSumWithNonFloat) {
+					return errs
+				}
 			}
 		} else {
 			if floatValueCount == 0 && r.Sum == nil && r.BaseSum == nil {
-				return fmt.Errorf("no value or sum")
+				if report(i, "v", ErrNoValue) {
+					return errs
+				}
 			}
 		}
 
 	}
 
-	return nil
+	return errs
 }
 
 // ValidateName validates the SenML name
 func ValidateName(name string) error {
 	if len(name) == 0 {
-		return fmt.Errorf("empty name")
+		return ErrEmptyName
 	}
 	validName, err := regexp.Compile(`^[a-zA-Z0-9]+[a-zA-Z0-9-:./_]*$`)
 	if err != nil {
 		return fmt.Errorf("invalid regex for name validation: %s", err)
 	}
 	if !validName.MatchString(name) {
-		return fmt.Errorf("invalid name: must begin with alphanumeric and contain alphanumeric or one of - : . / _")
+		return ErrInvalidName
 	}
 	return nil
 }
@@ -2,6 +2,7 @@ package senml
 
 import (
 	"encoding/json"
+	"errors"
 	"math"
 	"testing"
 	"time"
@@ -194,11 +195,53 @@ func TestNormalize(t *testing.T) {
 		}
 	})
 
+	t.Run("Injected clock", func(t *testing.T) {
+		now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		clock := func() time.Time { return now }
+		p1, p2 := referencePack(), referencePack()
+		p1.Normalize(SetClock(clock))
+		p2.Normalize(SetClock(clock))
+		if p1[0].Time != float64(now.Unix())-46.67 {
+			t.Fatalf("Time is not relative to the clock. Got %f", p1[0].Time)
+		}
+		if stringifyPack(p1) != stringifyPack(p2) {
+			t.Fatalf("Normalization is not deterministic: %s != %s", stringifyPack(p1), stringifyPack(p2))
+		}
+	})
+
+	t.Run("Keep relative time", func(t *testing.T) {
+		p := referencePack()
+		p.Normalize(SetKeepRelativeTime)
+		for i, expected := range []float64{-46.67, -46.67, -45.67, -45.67} {
+			if p[i].Time != expected {
+				t.Fatalf("Time was not kept relative in record %d. Got %f instead of %f", i, p[i].Time, expected)
+			}
+		}
+
+		p = referencePack(true)
+		p.Normalize(SetKeepRelativeTime)
+		if p[0].Time != 946684799 {
+			t.Fatalf("Absolute time was not resolved. Got %f", p[0].Time)
+		}
+	})
+
+	t.Run("Normalized", func(t *testing.T) {
+		p := referencePack(true)
+		normalized := p.Normalized()
+		if p[0].BaseName != "dev123" || p[0].Name != "temp" {
+			t.Fatalf("Pack was modified: %s", stringifyPack(p))
+		}
+		p.Normalize()
+		if stringifyPack(normalized) != stringifyPack(p) {
+			t.Fatalf("Expected: %s. Got: %s", stringifyPack(p), stringifyPack(normalized))
+		}
+	})
 }
 
 func TestClone(t *testing.T) {
 	p := referencePack()
 	p[0].XMLName = new(bool)
+	p[0].Extensions = map[string]interface{}{"label": "kitchen"}
 
 	c := p.Clone()
 
@@ -207,8 +250,10 @@ func TestClone(t *testing.T) {
 	*p[0].BaseVersion = 123
 	p[0].Time = 123
 	p[0].StringValue = "changed"
+	p[0].Extensions["label"] = "changed"
 
 	if *p[0].XMLName == *c[0].XMLName ||
+		p[0].Extensions["label"] == c[0].Extensions["label"] ||
 		*p[0].Value == *c[0].Value ||
 		*p[0].BaseVersion == *c[0].BaseVersion ||
 		p[0].Time == c[0].Time ||
@@ -420,6 +465,76 @@ func TestValidate(t *testing.T) {
 	})
 }
 
+func TestValidationError(t *testing.T) {
+
+	t.Run("first violation", func(t *testing.T) {
+		value := 1.0
+		pack := Pack{
+			{Name: "dev", Value: &value},
+			{Name: "dev", Value: &value, StringValue: "on"},
+			{Name: "dev"},
+		}
+		err := pack.Validate()
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Fatalf("Error is not a validation error: %v", err)
+		}
+		if verr.Index != 1 || verr.Field != "v" {
+			t.Fatalf("Unexpected record index or field: %s", err)
+		}
+		if !errors.Is(err, ErrTooManyValues) {
+			t.Fatalf("Error does not match its kind: %s", err)
+		}
+	})
+
+	t.Run("all violations", func(t *testing.T) {
+		value := 1.0
+		bver := 5
+		pack := Pack{
+			{Name: "dev", Value: &value},
+			{Name: "-dev", Value: &value, BaseVersion: &bver},
+			{Name: "dev"},
+			{Name: "dev", StringValue: "on", Sum: &value},
+		}
+		err := pack.ValidateAll()
+		errs, ok := err.(ValidationErrors)
+		if !ok {
+			t.Fatalf("Error is not a list of validation errors: %v", err)
+		}
+
+		expected := []ValidationError{
+			{1, "bver", ErrVersionChange},
+			{1, "n", ErrInvalidName},
+			{2, "v", ErrNoValue},
+			{3, "s", ErrSumWithNonFloat},
+		}
+		if len(errs) != len(expected) {
+			t.Fatalf("Expected %d errors. Got %d: %s", len(expected), len(errs), err)
+		}
+		for i := range expected {
+			if errs[i].Index != expected[i].Index || errs[i].Field != expected[i].Field || errs[i].Err != expected[i].Err {
+				t.Fatalf("Expected error: %s. Got: %s", &expected[i], errs[i])
+			}
+		}
+		if !errors.Is(err, ErrNoValue) {
+			t.Fatalf("Errors do not match a contained kind: %s", err)
+		}
+		if errors.Is(err, ErrEmptyName) {
+			t.Fatalf("Errors match a kind that is not contained: %s", err)
+		}
+	})
+
+	t.Run("valid pack", func(t *testing.T) {
+		value := 1.0
+		pack := Pack{
+			{Name: "dev", Value: &value},
+		}
+		if err := pack.ValidateAll(); err != nil {
+			t.Fatalf("Error for valid pack: %s", err)
+		}
+	})
+}
+
 func TestValidateName(t *testing.T) {
 	t.Run("valid names", func(t *testing.T) {
 		names := []string{"Aa-:./_", "urn:dev:ow:10e2073a", "http://example.com"}
@@ -0,0 +1,131 @@
+package senml
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Times and durations are converted through the shortest decimal representation of the seconds,
+// so that e.g. a time of 1276020076.001 is exactly 1ms past the second rather than the nearest binary fraction.
+// Absolute times keep microsecond precision, while relative times and durations of up to a few weeks keep nanosecond precision.
+
+// IsRelativeTime reports whether the resolved time in seconds is relative to the current time according to:
+// https://tools.ietf.org/html/rfc8428#section-4.5.3
+func IsRelativeTime(t float64) bool {
+	return t < pivot
+}
+
+// TimeAt returns the time of the record, adding the BaseTime of the record, if any.
+// Relative times are resolved against the given time, e.g. the time the record was received.
+func (r Record) TimeAt(now time.Time) time.Time {
+	return resolveTime(r.BaseTime+r.Time, now)
+}
+
+// SetTime sets the absolute time of the record. The BaseTime of the record, if any, is cleared.
+// As in Builder.At, the zero time resets the record to the time of the reading, i.e. a Time of 0.
+// Other times before 2**28 seconds since the epoch (1978-07-04) cannot be represented, as they denote relative times.
+func (r *Record) SetTime(t time.Time) {
+	r.BaseTime = 0
+	if t.IsZero() {
+		r.Time = 0
+		return
+	}
+	r.Time = toSeconds(t.Unix(), int64(t.Nanosecond()))
+}
+
+// SetRelativeTime sets the time of the record relative to the current time, e.g. -time.Second for one second ago.
+// The BaseTime of the record, if any, is cleared.
+func (r *Record) SetRelativeTime(d time.Duration) {
+	r.BaseTime = 0
+	r.Time = durationToSeconds(d)
+}
+
+// UpdateDuration returns the UpdateTime of the record as a duration
+func (r Record) UpdateDuration() time.Duration {
+	return secondsToDuration(r.UpdateTime)
+}
+
+// SetUpdateDuration sets the UpdateTime of the record from a duration
+func (r *Record) SetUpdateDuration(d time.Duration) {
+	r.UpdateTime = durationToSeconds(d)
+}
+
+// Times returns the time of every record in the pack, applying base times.
+// Relative times are resolved against the given time, e.g. the time the pack was received.
+func (p Pack) Times(now time.Time) []time.Time {
+	times := make([]time.Time, len(p))
+	var btime float64
+	for i, r := range p {
+		if r.BaseTime != 0 {
+			btime = r.BaseTime
+		}
+		times[i] = resolveTime(btime+r.Time, now)
+	}
+	return times
+}
+
+func resolveTime(t float64, now time.Time) time.Time {
+	if IsRelativeTime(t) {
+		return now.Add(secondsToDuration(t))
+	}
+	sec, nsec := splitSeconds(t)
+	return time.Unix(sec, nsec)
+}
+
+func secondsToDuration(s float64) time.Duration {
+	sec, nsec := splitSeconds(s)
+	return time.Duration(sec)*time.Second + time.Duration(nsec)
+}
+
+func durationToSeconds(d time.Duration) float64 {
+	return toSeconds(int64(d/time.Second), int64(d%time.Second))
+}
+
+// splitSeconds splits the shortest decimal representation of the seconds into whole seconds and nanoseconds, rounding to the nearest nanosecond.
+// Both parts have the sign of the seconds.
+func splitSeconds(s float64) (sec, nsec int64) {
+	if math.IsNaN(s) || math.IsInf(s, 0) || math.Abs(s) >= math.MaxInt64/1e9 {
+		return 0, 0
+	}
+	str := strconv.FormatFloat(math.Abs(s), 'f', -1, 64)
+	frac := ""
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		str, frac = str[:i], str[i+1:]
+	}
+	sec, _ = strconv.ParseInt(str, 10, 64)
+	for i := 0; i < 9; i++ {
+		nsec *= 10
+		if i < len(frac) {
+			nsec += int64(frac[i] - '0')
+		}
+	}
+	if len(frac) > 9 && frac[9] >= '5' {
+		nsec++
+		if nsec == 1e9 {
+			sec, nsec = sec+1, 0
+		}
+	}
+	if s < 0 {
+		return -sec, -nsec
+	}
+	return sec, nsec
+}
+
+// toSeconds returns the float nearest to the seconds and nanoseconds, which may have different signs
+func toSeconds(sec, nsec int64) float64 {
+	sec, nsec = sec+nsec/1e9, nsec%1e9
+	if sec > 0 && nsec < 0 {
+		sec, nsec = sec-1, nsec+1e9
+	} else if sec < 0 && nsec > 0 {
+		sec, nsec = sec+1, nsec-1e9
+	}
+
+	sign := ""
+	if sec < 0 || nsec < 0 {
+		sign, sec, nsec = "-", -sec, -nsec
+	}
+	f, _ := strconv.ParseFloat(sign+strconv.FormatInt(sec, 10)+"."+strconv.FormatInt(1e9+nsec, 10)[1:], 64)
+	return f
+}
@@ -0,0 +1,108 @@
+package senml
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordTime(t *testing.T) {
+	now := time.Date(2020, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("absolute time", func(t *testing.T) {
+		r := Record{Time: 1276020076.001}
+		expected := time.Unix(1276020076, 1000000)
+		if got := r.TimeAt(now); !got.Equal(expected) {
+			t.Fatalf("Expected %s. Got %s", expected, got)
+		}
+	})
+
+	t.Run("absolute time with base time", func(t *testing.T) {
+		r := Record{BaseTime: 1276020000, Time: 76.5}
+		expected := time.Unix(1276020076, 500000000)
+		if got := r.TimeAt(now); !got.Equal(expected) {
+			t.Fatalf("Expected %s. Got %s", expected, got)
+		}
+	})
+
+	t.Run("relative time", func(t *testing.T) {
+		r := Record{Time: -5.000000001}
+		expected := now.Add(-5*time.Second - time.Nanosecond)
+		if got := r.TimeAt(now); !got.Equal(expected) {
+			t.Fatalf("Expected %s. Got %s", expected, got)
+		}
+	})
+
+	t.Run("set absolute time", func(t *testing.T) {
+		for _, nsec := range []int{0, 1000, 999999000, 123456000} {
+			tm := time.Unix(1585742400, int64(nsec))
+			var r Record
+			r.BaseTime = 10
+			r.SetTime(tm)
+			if r.BaseTime != 0 {
+				t.Fatalf("Base time was not cleared")
+			}
+			if got := r.TimeAt(now); !got.Equal(tm) {
+				t.Fatalf("Expected %s. Got %s", tm, got)
+			}
+		}
+	})
+
+	t.Run("set zero time", func(t *testing.T) {
+		r := Record{BaseTime: 10, Time: 5}
+		r.SetTime(time.Time{})
+		if r.BaseTime != 0 || r.Time != 0 {
+			t.Fatalf("Expected time of the reading. Got base time %v, time %v", r.BaseTime, r.Time)
+		}
+		if got := r.TimeAt(now); !got.Equal(now) {
+			t.Fatalf("Expected %s. Got %s", now, got)
+		}
+	})
+
+	t.Run("set relative time", func(t *testing.T) {
+		for _, d := range []time.Duration{time.Nanosecond, -time.Nanosecond, -1500 * time.Millisecond, -time.Hour - 7*time.Nanosecond, 123456789 * time.Nanosecond} {
+			var r Record
+			r.SetRelativeTime(d)
+			if got := r.TimeAt(now); !got.Equal(now.Add(d)) {
+				t.Fatalf("Expected %s. Got %s", now.Add(d), got)
+			}
+		}
+	})
+
+	t.Run("update duration", func(t *testing.T) {
+		for _, d := range []time.Duration{0, time.Nanosecond, 10 * time.Second, 1500*time.Millisecond + 1, 24*time.Hour - 1} {
+			var r Record
+			r.SetUpdateDuration(d)
+			if got := r.UpdateDuration(); got != d {
+				t.Fatalf("Expected %s. Got %s", d, got)
+			}
+		}
+		if d := (Record{UpdateTime: 0.1}).UpdateDuration(); d != 100*time.Millisecond {
+			t.Fatalf("Expected 100ms. Got %s", d)
+		}
+	})
+}
+
+func TestPackTimes(t *testing.T) {
+	now := time.Date(2020, 4, 1, 12, 0, 0, 0, time.UTC)
+	p := Pack{
+		{BaseTime: 1276020076.001, Time: -1},
+		{},
+		{BaseTime: -10, Time: 0.25},
+		{Time: 1320067464},
+	}
+	expected := []time.Time{
+		time.Unix(1276020075, 1000000),
+		time.Unix(1276020076, 1000000),
+		now.Add(-9750 * time.Millisecond),
+		time.Unix(1320067454, 0),
+	}
+	for i, got := range p.Times(now) {
+		if !got.Equal(expected[i]) {
+			t.Fatalf("Expected %s for record %d. Got %s", expected[i], i, got)
+		}
+	}
+
+	if !IsRelativeTime(-10) || IsRelativeTime(1276020076) {
+		t.Fatalf("Unexpected relative time check")
+	}
+}
@@ -0,0 +1,206 @@
+package senml
+
+import (
+	"sort"
+	"sync"
+)
+
+// Unit describes a SenML unit
+type Unit struct {
+	// Symbol is the unit as used in the Unit and BaseUnit fields.
+	Symbol string
+	// Description is the name of the unit.
+	Description string
+	// Quantity is the kind of quantity measured in the unit, e.g. "length".
+	Quantity string
+	// Recommended is false for units which are NOT RECOMMENDED by RFC8428 and should only be used to interoperate with legacy systems.
+	Recommended bool
+	// Secondary is true for secondary units which are not intended for use in the Unit field by default:
+	// https://tools.ietf.org/html/rfc8798#section-3
+	Secondary bool
+	// ConvertsTo is the symbol of the unit values are converted to, if any.
+	// It is the primary unit of secondary units, and e.g. K for Cel.
+	ConvertsTo string
+	// Scale and Offset convert a value to the ConvertsTo unit: Scale * value + Offset.
+	Scale  float64
+	Offset float64
+}
+
+// UnitRegistry holds units keyed by symbol. It is safe for concurrent use.
+type UnitRegistry struct {
+	mu    sync.RWMutex
+	units map[string]Unit
+}
+
+// Units is the registry of SenML units, pre-registered with the units of RFC8428 and RFC8798
+var Units = newUnitRegistry()
+
+// NewUnitRegistry returns an empty unit registry
+func NewUnitRegistry() *UnitRegistry {
+	return &UnitRegistry{units: make(map[string]Unit)}
+}
+
+// Register adds the units, replacing any existing ones with the same symbol
+func (reg *UnitRegistry) Register(units ...Unit) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	for _, u := range units {
+		reg.units[u.Symbol] = u
+	}
+}
+
+// Lookup returns the unit with the given symbol
+func (reg *UnitRegistry) Lookup(symbol string) (Unit, bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	u, found := reg.units[symbol]
+	return u, found
+}
+
+// All returns the registered units ordered by symbol
+func (reg *UnitRegistry) All() []Unit {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	units := make([]Unit, 0, len(reg.units))
+	for _, u := range reg.units {
+		units = append(units, u)
+	}
+	sort.Slice(units, func(i, j int) bool { return units[i].Symbol < units[j].Symbol })
+	return units
+}
+
+func newUnitRegistry() *UnitRegistry {
+	reg := NewUnitRegistry()
+	primary := func(symbol, description, quantity string) Unit {
+		return Unit{Symbol: symbol, Description: description, Quantity: quantity, Recommended: true}
+	}
+	notRecommended := func(u Unit) Unit {
+		u.Recommended = false
+		return u
+	}
+	convertible := func(u Unit, to string, scale, offset float64) Unit {
+		u.ConvertsTo, u.Scale, u.Offset = to, scale, offset
+		return u
+	}
+	secondary := func(symbol, description, quantity, primary string, scale, offset float64) Unit {
+		return Unit{Symbol: symbol, Description: description, Quantity: quantity, Recommended: true,
+			Secondary: true, ConvertsTo: primary, Scale: scale, Offset: offset}
+	}
+
+	// https://tools.ietf.org/html/rfc8428#section-12.1
+	reg.Register(
+		primary(UnitMeter, "meter", "length"),
+		primary(UnitKilogram, "kilogram", "mass"),
+		notRecommended(convertible(primary(UnitGram, "gram", "mass"), UnitKilogram, 1.0/1000, 0)),
+		primary(UnitSecond, "second", "time"),
+		primary(UnitAmpere, "ampere", "electric current"),
+		primary(UnitKelvin, "kelvin", "temperature"),
+		primary(UnitCandela, "candela", "luminous intensity"),
+		primary(UnitMole, "mole", "amount of substance"),
+		primary(UnitHertz, "hertz", "frequency"),
+		primary(UnitRadian, "radian", "angle"),
+		primary(UnitSteradian, "steradian", "solid angle"),
+		primary(UnitNewton, "newton", "force"),
+		primary(UnitPascal, "pascal", "pressure"),
+		primary(UnitJoule, "joule", "energy"),
+		primary(UnitWatt, "watt", "power"),
+		primary(UnitCoulomb, "coulomb", "electric charge"),
+		primary(UnitVolt, "volt", "voltage"),
+		primary(UnitFarad, "farad", "capacitance"),
+		primary(UnitOhm, "ohm", "resistance"),
+		primary(UnitSiemens, "siemens", "conductance"),
+		primary(UnitWeber, "weber", "magnetic flux"),
+		primary(UnitTesla, "tesla", "magnetic flux density"),
+		primary(UnitHenry, "henry", "inductance"),
+		convertible(primary(UnitCelsius, "degrees Celsius", "temperature"), UnitKelvin, 1, 273.15),
+		primary(UnitLumen, "lumen", "luminous flux"),
+		primary(UnitLux, "lux", "illuminance"),
+		primary(UnitBecquerel, "becquerel", "radioactivity"),
+		primary(UnitGray, "gray", "absorbed dose"),
+		primary(UnitSievert, "sievert", "equivalent dose"),
+		primary(UnitKatal, "katal", "catalytic activity"),
+		primary(UnitSquareMeter, "square meter", "area"),
+		primary(UnitCubicMeter, "cubic meter", "volume"),
+		notRecommended(convertible(primary(UnitLiter, "liter", "volume"), UnitCubicMeter, 1.0/1000, 0)),
+		primary(UnitMeterPerSecond, "meter per second", "velocity"),
+		primary(UnitMeterPerSquareSecond, "meter per square second", "acceleration"),
+		primary(UnitCubicMeterPerSecond, "cubic meter per second", "flow rate"),
+		notRecommended(convertible(primary(UnitLiterPerSecond, "liter per second", "flow rate"), UnitCubicMeterPerSecond, 1.0/1000, 0)),
+		primary(UnitWattPerSquareMeter, "watt per square meter", "irradiance"),
+		primary(UnitCandelaPerSquareMeter, "candela per square meter", "luminance"),
+		primary(UnitBit, "bit", "information content"),
+		primary(UnitBitPerSecond, "bit per second", "data rate"),
+		primary(UnitLat, "degrees latitude", "latitude"),
+		primary(UnitLon, "degrees longitude", "longitude"),
+		primary(UnitpHValue, "pH value", "acidity"),
+		primary(UnitDecibel, "decibel", "logarithmic quantity"),
+		primary(UnitDecibelWatt, "decibel relative to 1 W", "power level"),
+		notRecommended(primary(UnitBel, "bel", "sound pressure level")),
+		primary(UnitCount, "1", "counter value"),
+		primary(UnitRatio, "1", "ratio"),
+		notRecommended(convertible(primary(UnitAbsoluteRatio, "1", "ratio"), UnitRatio, 1.0/100, 0)),
+		primary(UnitRelativeHumidity, "percentage", "relative humidity"),
+		primary(UnitEnergyLevelPercentage, "percentage", "remaining battery energy level"),
+		primary(UnitEnergyLevelSeconds, "seconds", "remaining battery energy level"),
+		primary(UnitEventRateOnePerSecond, "1 per second", "event rate"),
+		notRecommended(convertible(primary(UnitEventRateOnePerMinute, "1 per minute", "event rate"), UnitEventRateOnePerSecond, 1.0/60, 0)),
+		notRecommended(convertible(primary(UnitHeartRateBeatsPerMinute, "1 per minute", "heart rate"), UnitEventRateOnePerSecond, 1.0/60, 0)),
+		notRecommended(primary(UnitHeartBeats, "1", "cumulative number of heart beats")),
+		primary(UnitSiemensPerMeter, "siemens per meter", "conductivity"),
+	)
+
+	// https://tools.ietf.org/html/rfc8798#section-2
+	reg.Register(
+		primary(UnitByte, "byte", "information content"),
+		primary(UnitVoltAmpere, "volt-ampere", "apparent power"),
+		primary(UnitVoltAmpereSecond, "volt-ampere second", "apparent energy"),
+		primary(UnitVoltAmpereReactive, "volt-ampere reactive", "reactive power"),
+		primary(UnitVoltAmpereReactiveSecond, "volt-ampere-reactive second", "reactive energy"),
+		primary(UnitJoulePerMeter, "joule per meter", "energy per distance"),
+		primary(UnitKilogramPerCubicMeter, "kilogram per cubic meter", "mass density"),
+		primary(UnitDegree, "degree", "angle"),
+	)
+
+	// https://tools.ietf.org/html/rfc8798#section-3
+	reg.Register(
+		secondary(UnitMillisecond, "millisecond", "time", UnitSecond, 1.0/1000, 0),
+		secondary(UnitMinute, "minute", "time", UnitSecond, 60, 0),
+		secondary(UnitHour, "hour", "time", UnitSecond, 3600, 0),
+		secondary(UnitMegahertz, "megahertz", "frequency", UnitHertz, 1000000, 0),
+		secondary(UnitKilowatt, "kilowatt", "power", UnitWatt, 1000, 0),
+		secondary(UnitKilovoltAmpere, "kilovolt-ampere", "apparent power", UnitVoltAmpere, 1000, 0),
+		secondary(UnitKilovar, "kilovar", "reactive power", UnitVoltAmpereReactive, 1000, 0),
+		secondary(UnitAmpereHour, "ampere-hour", "electric charge", UnitCoulomb, 3600, 0),
+		secondary(UnitWattHour, "watt-hour", "energy", UnitJoule, 3600, 0),
+		secondary(UnitKilowattHour, "kilowatt-hour", "energy", UnitJoule, 3600000, 0),
+		secondary(UnitVarHour, "var-hour", "reactive energy", UnitVoltAmpereReactiveSecond, 3600, 0),
+		secondary(UnitKilovarHour, "kilovar-hour", "reactive energy", UnitVoltAmpereReactiveSecond, 3600000, 0),
+		secondary(UnitKilovoltAmpereHour, "kilovolt-ampere-hour", "apparent energy", UnitVoltAmpereSecond, 3600000, 0),
+		secondary(UnitWattHourPerKilometer, "watt-hour per kilometer", "energy per distance", UnitJoulePerMeter, 3.6, 0),
+		secondary(UnitKibibyte, "kibibyte", "information content", UnitByte, 1024, 0),
+		secondary(UnitGigabyte, "gigabyte", "information content", UnitByte, 1e9, 0),
+		secondary(UnitMegabitPerSecond, "megabit per second", "data rate", UnitBitPerSecond, 1000000, 0),
+		secondary(UnitBytePerSecond, "byte per second", "data rate", UnitBitPerSecond, 8, 0),
+		secondary(UnitMegabytePerSecond, "megabyte per second", "data rate", UnitBitPerSecond, 8000000, 0),
+		secondary(UnitMillivolt, "millivolt", "voltage", UnitVolt, 1.0/1000, 0),
+		secondary(UnitMilliampere, "milliampere", "electric current", UnitAmpere, 1.0/1000, 0),
+		secondary(UnitDecibelMilliwatt, "decibel relative to 1 milliwatt", "power level", UnitDecibelWatt, 1, -30),
+		secondary(UnitMicrogramPerCubicMeter, "microgram per cubic meter", "mass density", UnitKilogramPerCubicMeter, 1e-9, 0),
+		secondary(UnitMillimeterPerHour, "millimeter per hour", "velocity", UnitMeterPerSecond, 1.0/3600000, 0),
+		secondary(UnitMeterPerHour, "meter per hour", "velocity", UnitMeterPerSecond, 1.0/3600, 0),
+		secondary(UnitPartsPerMillion, "parts per million", "ratio", UnitRatio, 1e-6, 0),
+		secondary(UnitPerHundred, "percent", "ratio", UnitRatio, 1.0/100, 0),
+		secondary(UnitPerThousand, "permille", "ratio", UnitRatio, 1.0/1000, 0),
+		secondary(UnitHectopascal, "hectopascal", "pressure", UnitPascal, 100, 0),
+		secondary(UnitMillimeter, "millimeter", "length", UnitMeter, 1.0/1000, 0),
+		secondary(UnitCentimeter, "centimeter", "length", UnitMeter, 1.0/100, 0),
+		secondary(UnitKilometer, "kilometer", "length", UnitMeter, 1000, 0),
+		secondary(UnitKilometerPerHour, "kilometer per hour", "velocity", UnitMeterPerSecond, 1/3.6, 0),
+	)
+
+	// not registered with IANA
+	reg.Register(
+		secondary(UnitCustomDegreesFahrenheit, "degrees Fahrenheit", "temperature", UnitCelsius, 5.0/9, -160.0/9),
+	)
+	return reg
+}
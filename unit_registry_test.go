@@ -0,0 +1,126 @@
+package senml
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUnits(t *testing.T) {
+
+	t.Run("primary units", func(t *testing.T) {
+		for _, symbol := range []string{"kg", "W", "Cel", "m3/s", "S/m", "B", "kg/m3", "deg"} {
+			u, found := Units.Lookup(symbol)
+			if !found {
+				t.Fatalf("Unit %s is not registered", symbol)
+			}
+			if u.Secondary {
+				t.Fatalf("Unit %s is registered as secondary", symbol)
+			}
+		}
+	})
+
+	t.Run("not recommended units", func(t *testing.T) {
+		for _, symbol := range []string{UnitGram, UnitLiter, UnitLiterPerSecond, UnitBel, UnitAbsoluteRatio, UnitEventRateOnePerMinute, UnitHeartRateBeatsPerMinute, UnitHeartBeats} {
+			if u, _ := Units.Lookup(symbol); u.Recommended {
+				t.Fatalf("Unit %s is recommended", symbol)
+			}
+		}
+	})
+
+	t.Run("secondary units", func(t *testing.T) {
+		tests := []struct {
+			symbol  string
+			primary string
+			scale   float64
+			offset  float64
+		}{
+			{"ms", "s", 0.001, 0},
+			{"km/h", "m/s", 1 / 3.6, 0},
+			{"kWh", "J", 3600000, 0},
+			{"dBm", "dBW", 1, -30},
+			{"%", "/", 0.01, 0},
+			{"degF", "Cel", 5.0 / 9, -160.0 / 9},
+		}
+		for _, test := range tests {
+			u, found := Units.Lookup(test.symbol)
+			if !found {
+				t.Fatalf("Unit %s is not registered", test.symbol)
+			}
+			if u.ConvertsTo != test.primary || u.Scale != test.scale || u.Offset != test.offset {
+				t.Fatalf("Unexpected conversion for %s: %+v", test.symbol, u)
+			}
+		}
+	})
+
+	t.Run("RFC8798 secondary units", func(t *testing.T) {
+		// the 33 units of https://www.iana.org/assignments/senml/senml.xhtml#secondary-units
+		registered := []string{"ms", "min", "h", "MHz", "kW", "kVA", "kvar", "Ah", "Wh", "kWh", "varh", "kvarh", "kVAh",
+			"Wh/km", "KiB", "GB", "Mbit/s", "B/s", "MB/s", "mV", "mA", "dBm", "ug/m3", "mm/h", "m/h", "ppm", "/100", "/1000",
+			"hPa", "mm", "cm", "km", "km/h"}
+		for _, symbol := range registered {
+			if u, found := Units.Lookup(symbol); !found || !u.Secondary {
+				t.Fatalf("Unit %s is not registered as secondary", symbol)
+			}
+		}
+		count := 0
+		for _, u := range Units.All() {
+			if u.Secondary && u.Symbol != UnitCustomDegreesFahrenheit {
+				count++
+			}
+		}
+		if count != len(registered) {
+			t.Fatalf("Expected %d secondary units. Got %d", len(registered), count)
+		}
+	})
+
+	t.Run("all units", func(t *testing.T) {
+		all := Units.All()
+		if len(all) < 90 {
+			t.Fatalf("Expected all units. Got %d", len(all))
+		}
+		for i := 1; i < len(all); i++ {
+			if all[i-1].Symbol >= all[i].Symbol {
+				t.Fatalf("Units are not ordered: %s, %s", all[i-1].Symbol, all[i].Symbol)
+			}
+		}
+		for _, u := range all {
+			if _, found := Units.Lookup(u.ConvertsTo); u.ConvertsTo != "" && !found {
+				t.Fatalf("Unit %s converts to unregistered unit %s", u.Symbol, u.ConvertsTo)
+			}
+		}
+	})
+
+	t.Run("custom registry", func(t *testing.T) {
+		reg := NewUnitRegistry()
+		reg.Register(Unit{Symbol: "furlong", Description: "furlong", Quantity: "length", ConvertsTo: UnitMeter, Scale: 201.168})
+		if u, found := reg.Lookup("furlong"); !found || u.Scale != 201.168 {
+			t.Fatalf("Custom unit was not registered: %+v", u)
+		}
+		if _, found := reg.Lookup(UnitMeter); found {
+			t.Fatalf("Custom registry is not empty")
+		}
+	})
+}
+
+func TestValidateStrictUnits(t *testing.T) {
+	value := 1.0
+	p := Pack{{BaseUnit: "Cel", Name: "temp", Value: &value}, {Name: "speed", Unit: "km/h", Value: &value}}
+	if err := p.Validate(SetStrictUnits); err != nil {
+		t.Fatalf("Error for registered units: %s", err)
+	}
+
+	p[1].Unit = "mph"
+	if err := p.Validate(); err != nil {
+		t.Fatalf("Error for unknown unit without strict validation: %s", err)
+	}
+	err := p.Validate(SetStrictUnits)
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Index != 1 || verr.Field != "u" || !errors.Is(err, ErrUnknownUnit) {
+		t.Fatalf("Expected unknown unit error for record 1. Got: %v", err)
+	}
+
+	p[0].BaseUnit = "degC"
+	if err := p.ValidateAll(SetStrictUnits); len(err.(ValidationErrors)) != 2 {
+		t.Fatalf("Expected two unknown unit errors. Got: %v", err)
+	}
+}
@@ -4,7 +4,7 @@ package senml
 // https://tools.ietf.org/html/rfc8428#section-12.1
 const (
 	UnitMeter                   = "m"        // meter
-	UnitKilogram                = "k"        // kilogram
+	UnitKilogram                = "kg"       // kilogram
 	UnitGram                    = "g"        // gram - NOT RECOMMENDED
 	UnitSecond                  = "s"        // second
 	UnitAmpere                  = "A"        // ampere
@@ -17,7 +17,7 @@ const (
 	UnitNewton                  = "N"        // newton
 	UnitPascal                  = "Pa"       // pascal
 	UnitJoule                   = "J"        // joule
-	UnitWatt                    = "watt"     // watt
+	UnitWatt                    = "W"        // watt
 	UnitCoulomb                 = "C"        // coulomb
 	UnitVolt                    = "V"        // volt
 	UnitFarad                   = "F"        // farad
@@ -62,3 +62,55 @@ const (
 	UnitHeartBeats              = "beats"    // 1 (cumulative number of heart beats) - NOT RECOMMENDED
 	UnitSiemensPerMeter         = "S/m"      // siemens per meter (conductivity)
 )
+
+// Additional primary units of the SenML Units Registry:
+// https://tools.ietf.org/html/rfc8798#section-2
+const (
+	UnitByte                     = "B"     // byte (information content)
+	UnitVoltAmpere               = "VA"    // volt-ampere (apparent power)
+	UnitVoltAmpereSecond         = "VAs"   // volt-ampere second (apparent energy)
+	UnitVoltAmpereReactive       = "var"   // volt-ampere reactive (reactive power)
+	UnitVoltAmpereReactiveSecond = "vars"  // volt-ampere-reactive second (reactive energy)
+	UnitJoulePerMeter            = "J/m"   // joule per meter (energy per distance)
+	UnitKilogramPerCubicMeter    = "kg/m3" // kilogram per cubic meter (mass density, mass concentration)
+	UnitDegree                   = "deg"   // degree (angle)
+)
+
+// SenML Secondary Units Registry:
+// https://tools.ietf.org/html/rfc8798#section-3
+const (
+	UnitMillisecond             = "ms"     // millisecond
+	UnitMinute                  = "min"    // minute
+	UnitHour                    = "h"      // hour
+	UnitMegahertz               = "MHz"    // megahertz
+	UnitKilowatt                = "kW"     // kilowatt
+	UnitKilovoltAmpere          = "kVA"    // kilovolt-ampere
+	UnitKilovar                 = "kvar"   // kilovar
+	UnitAmpereHour              = "Ah"     // ampere-hour
+	UnitWattHour                = "Wh"     // watt-hour
+	UnitKilowattHour            = "kWh"    // kilowatt-hour
+	UnitVarHour                 = "varh"   // var-hour
+	UnitKilovarHour             = "kvarh"  // kilovar-hour
+	UnitKilovoltAmpereHour      = "kVAh"   // kilovolt-ampere-hour
+	UnitWattHourPerKilometer    = "Wh/km"  // watt-hour per kilometer
+	UnitKibibyte                = "KiB"    // kibibyte
+	UnitGigabyte                = "GB"     // gigabyte
+	UnitMegabitPerSecond        = "Mbit/s" // megabit per second
+	UnitBytePerSecond           = "B/s"    // byte per second
+	UnitMegabytePerSecond       = "MB/s"   // megabyte per second
+	UnitMillivolt               = "mV"     // millivolt
+	UnitMilliampere             = "mA"     // milliampere
+	UnitDecibelMilliwatt        = "dBm"    // decibel relative to 1 milliwatt
+	UnitMicrogramPerCubicMeter  = "ug/m3"  // microgram per cubic meter
+	UnitMillimeterPerHour       = "mm/h"   // millimeter per hour
+	UnitMeterPerHour            = "m/h"    // meter per hour
+	UnitPartsPerMillion         = "ppm"    // parts per million
+	UnitPerHundred              = "/100"   // percent
+	UnitPerThousand             = "/1000"  // permille
+	UnitHectopascal             = "hPa"    // hectopascal
+	UnitMillimeter              = "mm"     // millimeter
+	UnitCentimeter              = "cm"     // centimeter
+	UnitKilometer               = "km"     // kilometer
+	UnitKilometerPerHour        = "km/h"   // kilometer per hour
+	UnitCustomDegreesFahrenheit = "degF"   // degrees Fahrenheit - not registered
+)
@@ -0,0 +1,113 @@
+package senml
+
+import (
+	"encoding/base64"
+	"strconv"
+)
+
+// ValueKind is the kind of value held by a record
+type ValueKind int
+
+// Kinds of values
+const (
+	KindNone    ValueKind = iota // no value nor sum
+	KindFloat                    // Value, with or without Sum
+	KindString                   // StringValue
+	KindBool                     // BoolValue
+	KindData                     // DataValue
+	KindSumOnly                  // Sum without a value
+)
+
+func (k ValueKind) String() string {
+	switch k {
+	case KindNone:
+		return "none"
+	case KindFloat:
+		return "float"
+	case KindString:
+		return "string"
+	case KindBool:
+		return "bool"
+	case KindData:
+		return "data"
+	case KindSumOnly:
+		return "sum"
+	}
+	return "ValueKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Kind returns the kind of value held by the record. The base value and base sum of the record are taken into account.
+// For records with more than one value, which are not valid, the first kind in the order of the ValueKind constants is returned.
+func (r Record) Kind() ValueKind {
+	switch {
+	case r.Value != nil || r.BaseValue != nil:
+		return KindFloat
+	case r.StringValue != "":
+		return KindString
+	case r.BoolValue != nil:
+		return KindBool
+	case r.DataValue != "":
+		return KindData
+	case r.Sum != nil || r.BaseSum != nil:
+		return KindSumOnly
+	}
+	return KindNone
+}
+
+// Float returns the float value of the record, including the base value of the record, and whether it has one
+func (r Record) Float() (float64, bool) {
+	if r.Value == nil && r.BaseValue == nil {
+		return 0, false
+	}
+	var v float64
+	if r.BaseValue != nil {
+		v = *r.BaseValue
+	}
+	if r.Value != nil {
+		v += *r.Value
+	}
+	return v, true
+}
+
+// Data returns the decoded data value of the record, or nil if it has none
+func (r Record) Data() ([]byte, error) {
+	if r.DataValue == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(r.DataValue)
+	if err != nil {
+		return nil, ErrInvalidDataValue
+	}
+	return b, nil
+}
+
+// Any returns the value of the record according to its kind:
+// float64 for KindFloat, string for KindString, bool for KindBool, []byte for KindData, the float64 sum for KindSumOnly, and nil otherwise.
+// Data values which cannot be decoded are returned as nil.
+func (r Record) Any() interface{} {
+	switch r.Kind() {
+	case KindFloat:
+		v, _ := r.Float()
+		return v
+	case KindString:
+		return r.StringValue
+	case KindBool:
+		return *r.BoolValue
+	case KindData:
+		b, err := r.Data()
+		if err != nil {
+			return nil
+		}
+		return b
+	case KindSumOnly:
+		var s float64
+		if r.BaseSum != nil {
+			s = *r.BaseSum
+		}
+		if r.Sum != nil {
+			s += *r.Sum
+		}
+		return s
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package senml
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRecordKind(t *testing.T) {
+	float := func(f float64) *float64 { return &f }
+	vb := true
+
+	tests := []struct {
+		name   string
+		record Record
+		kind   ValueKind
+		any    interface{}
+	}{
+		{"none", Record{Name: "a"}, KindNone, nil},
+		{"float", Record{Value: float(1.5)}, KindFloat, 1.5},
+		{"float with sum", Record{Value: float(1.5), Sum: float(10)}, KindFloat, 1.5},
+		{"base value", Record{BaseValue: float(10), Value: float(1.5)}, KindFloat, 11.5},
+		{"base value only", Record{BaseValue: float(10)}, KindFloat, 10.0},
+		{"string", Record{StringValue: "on"}, KindString, "on"},
+		{"bool", Record{BoolValue: &vb}, KindBool, true},
+		{"data", Record{DataValue: "aGkgCg"}, KindData, []byte("hi \n")},
+		{"invalid data", Record{DataValue: "aGkgCg=="}, KindData, nil},
+		{"sum only", Record{Sum: float(5)}, KindSumOnly, 5.0},
+		{"base sum", Record{BaseSum: float(5), Sum: float(1)}, KindSumOnly, 6.0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if kind := test.record.Kind(); kind != test.kind {
+				t.Fatalf("Expected kind %s. Got %s", test.kind, kind)
+			}
+			if any := test.record.Any(); !reflect.DeepEqual(any, test.any) {
+				t.Fatalf("Expected %#v. Got %#v", test.any, any)
+			}
+		})
+	}
+
+	if s := ValueKind(42).String(); s != "ValueKind(42)" {
+		t.Fatalf("Unexpected string for unknown kind: %s", s)
+	}
+}
+
+func TestRecordFloat(t *testing.T) {
+	v := 2.5
+	if f, ok := (Record{Value: &v}).Float(); !ok || f != 2.5 {
+		t.Fatalf("Expected 2.5. Got %v %v", f, ok)
+	}
+	if _, ok := (Record{StringValue: "on"}).Float(); ok {
+		t.Fatalf("Float value for string record")
+	}
+}
+
+func TestRecordData(t *testing.T) {
+	b, err := Record{DataValue: "aGkgCg"}.Data()
+	if err != nil || string(b) != "hi \n" {
+		t.Fatalf("Unexpected data: %q, %v", b, err)
+	}
+	b, err = Record{}.Data()
+	if err != nil || b != nil {
+		t.Fatalf("Unexpected data for record without data value: %q, %v", b, err)
+	}
+	_, err = Record{DataValue: "+/+/"}.Data()
+	if !errors.Is(err, ErrInvalidDataValue) {
+		t.Fatalf("Expected invalid data value error. Got: %v", err)
+	}
+}